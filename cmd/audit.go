@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lordzsolt/town/internal"
+	"github.com/lordzsolt/town/internal/cache"
+	gh "github.com/lordzsolt/town/internal/github"
+
+	"github.com/spf13/cobra"
+)
+
+var auditTeam string
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Check the organization's setup for problems",
+}
+
+var auditPermissionsCmd = &cobra.Command{
+	Use:   "permissions",
+	Short: "Compare a team's repository permissions with its CODEOWNERS ownership",
+	Long: `Cross-references the repositories whose CODEOWNERS name the team with the
+team's actual repository permissions, and reports:
+
+  - repositories the team owns without write access, so its review
+    requests can't be fulfilled
+  - repositories the team administers without being named in CODEOWNERS
+
+Exits with status 1 if any problem is found.
+With --offline, the snapshot created by 'town sync' is used.`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if org == "" {
+			return fmt.Errorf("organization is required: use --org flag or set default_org in config")
+		}
+		if auditTeam == "" {
+			auditTeam = settings.DefaultTeam
+		}
+		if auditTeam == "" {
+			return fmt.Errorf("team is required: use --team flag or set default_team in config")
+		}
+		// Team slugs are lowercase, in CODEOWNERS lookups and snapshots alike
+		auditTeam = strings.ToLower(auditTeam)
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		var files map[string]*cache.CodeownersFile
+		var permissions map[string]string
+		var snap *cache.Snapshot
+		if offline {
+			var err error
+			snap, err = loadSnapshot()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+			if snap.Team(auditTeam) == nil {
+				fmt.Fprintf(os.Stderr, "Error: team '%s' not found in the snapshot of '%s'\n", auditTeam, org)
+				os.Exit(1)
+			}
+			files, permissions = snap.CodeownersFiles(), snap.TeamRepos[auditTeam]
+		} else {
+			client, err := newClient()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+			ctx := context.Background()
+
+			teamRepos, err := gh.FetchTeamRepos(ctx, client, org, auditTeam)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error fetching team repos:", err)
+				os.Exit(1)
+			}
+			permissions = make(map[string]string, len(teamRepos))
+			for _, r := range teamRepos {
+				permissions[r.GetName()] = gh.Permission(r)
+			}
+
+			repos, err := gh.FetchAllRepos(ctx, client, org)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error fetching repos:", err)
+				os.Exit(1)
+			}
+			files = gh.FetchCodeowners(ctx, client, org, repos)
+		}
+
+		audit := internal.AuditPermissions(org, auditTeam, files, permissions)
+		ok := printPermissionAudit(audit)
+		if snap != nil {
+			printSnapshotAge(snap)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditPermissionsCmd)
+	auditPermissionsCmd.Flags().StringVarP(&auditTeam, "team", "t", "", "Team to audit (default: default_team from config)")
+	auditPermissionsCmd.RegisterFlagCompletionFunc("team", completeTeamFlag)
+}
+
+// printPermissionAudit prints the audit's findings. Returns false if there are any.
+func printPermissionAudit(audit *internal.PermissionAudit) bool {
+	fmt.Printf("Permissions of '%s' compared with CODEOWNERS:\n\n", audit.Team)
+
+	if len(audit.OwnedWithoutWrite) > 0 {
+		fmt.Printf("Owned without write access (%d):\n", len(audit.OwnedWithoutWrite))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, f := range audit.OwnedWithoutWrite {
+			fmt.Fprintf(w, "  ✗ %s\tpermission: %s\tCODEOWNERS: %s\n", f.Repo, orNone(f.Permission), strings.Join(f.Patterns, " "))
+		}
+		w.Flush()
+		fmt.Println()
+	}
+	if len(audit.AdminWithoutOwnership) > 0 {
+		fmt.Printf("Admin without CODEOWNERS ownership (%d):\n", len(audit.AdminWithoutOwnership))
+		for _, f := range audit.AdminWithoutOwnership {
+			fmt.Printf("  ✗ %s\n", f.Repo)
+		}
+		fmt.Println()
+	}
+
+	problems := len(audit.OwnedWithoutWrite) + len(audit.AdminWithoutOwnership)
+	fmt.Printf("%d owned repositories with write access, %d problems\n", audit.Consistent, problems)
+	return problems == 0
+}
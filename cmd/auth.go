@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	gh "github.com/lordzsolt/town/internal/github"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/spf13/cobra"
+)
+
+var (
+	loginWithToken bool
+	loginWeb       bool
+	refreshForce   bool
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage GitHub authentication",
+	Long: `Manage the GitHub token used by town.
+
+Tokens are looked up in this order:
+  1. the --token flag
+  2. TOWN_GITHUB_TOKEN, GITHUB_TOKEN or GH_TOKEN
+     (GH_ENTERPRISE_TOKEN or GITHUB_ENTERPRISE_TOKEN with --host)
+  3. the organization's token in the system keyring
+  4. the GitHub CLI ('gh auth token')
+  5. a git credential helper for the host
+  6. the default token in the system keyring
+
+If none is found and stdin is a terminal, town prompts for a token and
+stores it in the keyring as the default token.
+
+Fine-grained tokens are scoped to a single organization. Pass --org
+explicitly to login, logout and refresh to manage that organization's
+token; without it they manage the default token.`,
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Store a GitHub token in the keyring",
+	Long: `Validates a GitHub token and stores it in the system keyring, replacing
+any previously stored token.
+
+Use --web to log in through the browser with GitHub's device flow instead
+of creating a personal access token by hand.
+
+Use --with-token to read the token from stdin:
+  town auth login --with-token < token.txt`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var value string
+		var err error
+		switch {
+		case loginWeb:
+			value, err = deviceFlowLogin()
+		case loginWithToken:
+			value, err = gh.ReadToken(os.Stdin)
+		default:
+			value, err = gh.PromptForToken(host)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+
+		storeValidatedToken(value)
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove the GitHub token from the keyring",
+	Run: func(cmd *cobra.Command, args []string) {
+		deleted, err := gh.DeleteToken(host, keyringOrg())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error removing token from keyring:", err)
+			os.Exit(1)
+		}
+		if !deleted {
+			fmt.Printf("No %s stored in keyring.\n", keyringTokenName())
+			return
+		}
+		fmt.Printf("Removed %s from keyring.\n", keyringTokenName())
+
+		// Tell the user if town will keep authenticating via another source
+		if t, _ := gh.LookupToken(tokenOptions()); t != nil {
+			fmt.Printf("Note: a token is still available from %s.\n", t.Describe())
+		}
+	},
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which token is used, who it authenticates and what it can access",
+	Long: `Shows where the token was found, the authenticated user, the token's
+scopes and expiration, and the remaining API rate limit.
+
+If an organization is known (via --org or config), also checks that the
+token has the permissions town needs: Members (read) and Contents (read).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if usesApp() {
+			appAuthStatus()
+			return
+		}
+
+		t, err := gh.LookupToken(tokenOptions())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		if t == nil {
+			fmt.Println("Not logged in: no GitHub token found. Run 'town auth login'.")
+			os.Exit(1)
+		}
+
+		fmt.Printf("Host:         %s\n", host)
+		fmt.Printf("Token source: %s\n", t.Describe())
+		fmt.Printf("Token:        %s (%s)\n", maskToken(t.Value), gh.TokenType(t.Value))
+
+		client, err := gh.NewClient(gh.ClientOptions{Token: t.Value, Host: host})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+
+		ctx := context.Background()
+		info, err := gh.ValidateToken(ctx, client)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: token is not valid:", err)
+			os.Exit(1)
+		}
+		printTokenInfo(info)
+
+		if org == "" {
+			return
+		}
+
+		fmt.Println()
+		if !printPermissionChecks(ctx, client) {
+			os.Exit(1)
+		}
+	},
+}
+
+var authRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Replace the stored token if it is invalid or about to expire",
+	Long: `Validates the token stored in the keyring for --org, or the default token
+without --org. If it is missing, invalid or expires within 7 days (or --force
+is given), prompts for a new token and replaces the stored one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if stored, account := gh.StoredOrgToken(host, keyringOrg()); account != "" && !refreshForce {
+			client, err := gh.NewClient(gh.ClientOptions{Token: stored, Host: host})
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+
+			info, err := gh.ValidateToken(context.Background(), client)
+			switch {
+			case err != nil:
+				fmt.Printf("Stored token is not valid: %v\n\n", err)
+			case info.ExpiresWithin(7 * 24 * time.Hour):
+				fmt.Printf("Stored token expires at %s.\n\n", info.Expiration)
+			default:
+				fmt.Printf("Stored token for %s is valid, nothing to refresh. Use --force to replace it anyway.\n", info.Login)
+				return
+			}
+		}
+
+		value, err := gh.PromptForToken(host)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+
+		storeValidatedToken(value)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authLoginCmd, authLogoutCmd, authStatusCmd, authRefreshCmd)
+	authLoginCmd.Flags().BoolVar(&loginWithToken, "with-token", false, "Read the token from stdin")
+	authLoginCmd.Flags().BoolVar(&loginWeb, "web", false, "Log in through the browser using GitHub's device flow")
+	authLoginCmd.MarkFlagsMutuallyExclusive("with-token", "web")
+	authRefreshCmd.Flags().BoolVar(&refreshForce, "force", false, "Replace the stored token even if it is still valid")
+}
+
+// deviceFlowLogin shows a one-time code for the user to enter on GitHub and
+// waits for them to authorize town
+func deviceFlowLogin() (string, error) {
+	clientID := settings.OAuthClientID
+	if clientID == "" {
+		clientID = gh.DefaultOAuthClientID(host)
+	}
+
+	ctx := context.Background()
+	code, err := gh.RequestDeviceCode(ctx, host, clientID)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Open %s and enter the code: %s\n", code.VerificationURI, code.UserCode)
+	fmt.Println("Waiting for authorization...")
+
+	return gh.PollDeviceToken(ctx, host, clientID, code)
+}
+
+// appAuthStatus reports on the GitHub App configured in the config.
+// Installation tokens don't belong to a user, so only permissions are checked.
+func appAuthStatus() {
+	fmt.Printf("Authenticating as GitHub App %d\n", settings.AppID)
+	fmt.Printf("Private key:  %s\n", settings.AppPrivateKey)
+
+	client, err := newClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	limits, _, err := client.RateLimit.Get(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: installation token is not valid:", err)
+		os.Exit(1)
+	}
+	core := limits.GetCore()
+	fmt.Printf("Rate limit:   %d/%d remaining, resets at %s\n\n",
+		core.Remaining, core.Limit, core.Reset.Local().Format(time.TimeOnly))
+
+	if !printPermissionChecks(ctx, client) {
+		os.Exit(1)
+	}
+}
+
+// printPermissionChecks prints whether the client has the permissions town
+// needs in the current org. Returns false if any check failed.
+func printPermissionChecks(ctx context.Context, client *github.Client) bool {
+	if org == "" {
+		fmt.Println("Pass --org to check the token's permissions in an organization.")
+		return true
+	}
+
+	fmt.Printf("Permissions in '%s':\n", org)
+	ok := true
+	for _, check := range gh.CheckPermissions(ctx, client, org) {
+		if check.Err != nil {
+			ok = false
+			fmt.Printf("  ✗ %s: %v\n", check.Name, check.Err)
+		} else {
+			fmt.Printf("  ✓ %s\n", check.Name)
+		}
+	}
+	return ok
+}
+
+// storeValidatedToken stores the token in the keyring if GitHub accepts it
+func storeValidatedToken(value string) {
+	client, err := gh.NewClient(gh.ClientOptions{Token: value, Host: host})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+
+	info, err := gh.ValidateToken(context.Background(), client)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: token is not valid:", err)
+		os.Exit(1)
+	}
+
+	if err := gh.StoreToken(value, host, keyringOrg()); err != nil {
+		fmt.Fprintln(os.Stderr, "Error storing token in keyring:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Logged in as %s. Stored as the %s in keyring.\n", info.Login, keyringTokenName())
+
+	// Sources before the keyring take precedence over the stored token
+	if t, _ := gh.LookupToken(tokenOptions()); t != nil && t.Source != gh.SourceKeyring {
+		fmt.Printf("Note: town will keep using the token from %s.\n", t.Describe())
+	}
+}
+
+func printTokenInfo(info *gh.TokenInfo) {
+	fmt.Printf("Logged in as: %s\n", info.Login)
+	if info.EnterpriseVersion != "" {
+		fmt.Printf("Server:       GitHub Enterprise Server %s\n", info.EnterpriseVersion)
+	}
+	if len(info.Scopes) > 0 {
+		fmt.Printf("Scopes:       %s\n", strings.Join(info.Scopes, ", "))
+	}
+	if info.Expiration != "" {
+		fmt.Printf("Expires:      %s\n", info.Expiration)
+	}
+	fmt.Printf("Rate limit:   %d/%d remaining, resets at %s\n",
+		info.Rate.Remaining, info.Rate.Limit, info.Rate.Reset.Local().Format(time.TimeOnly))
+}
+
+// keyringOrg returns the org whose keyring token is managed: the org passed
+// explicitly via --org, or "" for the default token
+func keyringOrg() string {
+	if rootCmd.PersistentFlags().Changed("org") {
+		return org
+	}
+	return ""
+}
+
+func keyringTokenName() string {
+	if o := keyringOrg(); o != "" {
+		return fmt.Sprintf("token for '%s'", o)
+	}
+	return "default token"
+}
+
+// maskToken hides all but the prefix and last characters of a token
+func maskToken(t string) string {
+	if len(t) <= 8 {
+		return "****"
+	}
+	return t[:4] + "****" + t[len(t)-4:]
+}
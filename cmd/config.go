@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lordzsolt/town/internal"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configNoVerify  bool
+	configMigrateTo string
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage town configuration",
+	Long: `View and edit town's configuration file.
+
+get, set and unset work on the top-level settings, or on a profile's
+settings when --profile is given.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:               "get <key>",
+	Short:             "Print the value of a config key",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	Run: func(cmd *cobra.Command, args []string) {
+		key := lookupConfigKey(args[0])
+
+		value, err := key.Get(cfg, profile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		fmt.Println(value)
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a config key",
+	Long: `Sets a config key after validating its value.
+
+default_org and default_team are checked against the GitHub API (or the
+offline snapshot with --offline). Use --no-verify to skip the check.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeConfigKeys,
+	Run: func(cmd *cobra.Command, args []string) {
+		key := lookupConfigKey(args[0])
+		value := args[1]
+
+		if err := key.Validate(value); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+
+		if !configNoVerify {
+			if err := verifyConfigValue(key.Name, value); err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+		}
+
+		if err := key.Set(cfg, profile, value); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		saveConfigOrExit()
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:               "unset <key>",
+	Short:             "Remove a config key",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	Run: func(cmd *cobra.Command, args []string) {
+		key := lookupConfigKey(args[0])
+
+		if err := key.Unset(cfg, profile); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		saveConfigOrExit()
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all config keys and their values",
+	Run: func(cmd *cobra.Command, args []string) {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, key := range internal.ConfigKeys {
+			value, _ := key.Get(cfg, "")
+			fmt.Fprintf(w, "%s\t%s\t# %s\n", key.Name, value, key.Description)
+		}
+
+		for _, name := range cfg.ProfileNames() {
+			fmt.Fprintf(w, "\n[profile %s]\t\t\n", name)
+			for _, key := range internal.ConfigKeys {
+				if !key.InProfile {
+					continue
+				}
+				if value, _ := key.Get(cfg, name); value != "" {
+					fmt.Fprintf(w, "%s\t%s\t\n", key.Name, value)
+				}
+			}
+		}
+
+		if cfg.Project != nil {
+			fmt.Fprintf(w, "\n[project %s]\t\t\n", cfg.ProjectPath)
+			for _, key := range internal.ConfigKeys {
+				if value := key.GetProject(cfg); value != "" {
+					fmt.Fprintf(w, "%s\t%s\t\n", key.Name, value)
+				}
+			}
+		}
+
+		// Environment variables override the values above
+		header := false
+		for _, key := range internal.ConfigKeys {
+			value := os.Getenv(key.EnvVar())
+			if value == "" {
+				continue
+			}
+			if !header {
+				fmt.Fprintf(w, "\n[environment]\t\t\n")
+				header = true
+			}
+			fmt.Fprintf(w, "%s\t%s\t# %s\n", key.Name, value, key.EnvVar())
+		}
+		w.Flush()
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the path of the config file",
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := internal.ConfigPath()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		fmt.Println(path)
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the config file in $VISUAL or $EDITOR",
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := internal.ConfigPath()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+
+		// Create the file so there's something to edit
+		if !internal.ConfigExists() {
+			saveConfigOrExit()
+		}
+
+		editor := editorCommand()
+		edit := exec.Command(editor[0], append(editor[1:], path)...)
+		edit.Stdin = os.Stdin
+		edit.Stdout = os.Stdout
+		edit.Stderr = os.Stderr
+		if err := edit.Run(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error running editor:", err)
+			os.Exit(1)
+		}
+
+		// Check the result right away rather than on the next command
+		edited, err := internal.LoadConfig()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		if problems := edited.Validate(); len(problems) > 0 {
+			for _, problem := range problems {
+				fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", path, problem)
+			}
+			os.Exit(1)
+		}
+	},
+}
+
+var configUseProfileCmd = &cobra.Command{
+	Use:   "use-profile <name>",
+	Short: "Select the profile used when --profile isn't given",
+	Long: `Sets current_profile in the config, so that the named profile is applied
+on top of the top-level settings until another profile is selected.
+
+Pass an empty name ("") to stop using a profile.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProfiles,
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if name != "" {
+			if _, ok := cfg.Profiles[name]; !ok {
+				fmt.Fprintf(os.Stderr, "Error: profile '%s' not found in config\n", name)
+				os.Exit(1)
+			}
+		}
+
+		cfg.CurrentProfile = name
+		saveConfigOrExit()
+
+		if name == "" {
+			fmt.Println("No longer using a profile")
+		} else {
+			fmt.Printf("Now using profile '%s'\n", name)
+		}
+	},
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Convert the config file to another format",
+	Long: `Converts the config file to JSON, YAML or TOML, keeping all values.
+
+The new file is written next to the old one, which is kept as a .bak file.
+Comments are not carried over to the new file.`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if configMigrateTo == "" {
+			return fmt.Errorf("--to is required: use one of %s", strings.Join(internal.ConfigFormats, ", "))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		newPath, backupPath, err := internal.MigrateConfig(cfg, configMigrateTo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+
+		if len(cfg.UnknownKeys) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: unknown keys were not migrated: %s\n", strings.Join(cfg.UnknownKeys, ", "))
+		}
+		fmt.Printf("Migrated config to %s\n", newPath)
+		fmt.Printf("The old config was kept as %s\n", backupPath)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd, configSetCmd, configUnsetCmd, configListCmd, configPathCmd, configEditCmd, configUseProfileCmd, configMigrateCmd)
+	configSetCmd.Flags().BoolVar(&configNoVerify, "no-verify", false, "Don't check that the org or team exists")
+	configMigrateCmd.Flags().StringVar(&configMigrateTo, "to", "", "Format to convert to: json, yaml or toml")
+	configMigrateCmd.RegisterFlagCompletionFunc("to", cobra.FixedCompletions(internal.ConfigFormats, cobra.ShellCompDirectiveNoFileComp))
+
+	rootCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+}
+
+func lookupConfigKey(name string) *internal.ConfigKey {
+	key, err := internal.LookupConfigKey(name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		fmt.Fprintln(os.Stderr, "Run 'town config list' to see all keys.")
+		os.Exit(1)
+	}
+	return key
+}
+
+func saveConfigOrExit() {
+	if err := internal.SaveConfig(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "Error saving config:", err)
+		os.Exit(1)
+	}
+}
+
+// verifyConfigValue checks that an org or team set in the config exists
+func verifyConfigValue(key, value string) error {
+	switch key {
+	case "default_org":
+		if offline {
+			return nil // The snapshot only knows about a single org
+		}
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		if _, _, err := client.Organizations.Get(context.Background(), value); err != nil {
+			return fmt.Errorf("organization '%s' not found: %w", value, err)
+		}
+
+	case "default_team":
+		if org == "" {
+			return fmt.Errorf("organization is required to check the team: use --org flag or set default_org in config")
+		}
+		if offline {
+			snap, err := loadSnapshot()
+			if err != nil {
+				return err
+			}
+			for _, slug := range snap.TeamSlugs() {
+				if slug == value {
+					return nil
+				}
+			}
+			return fmt.Errorf("team '%s' not found in '%s'", value, org)
+		}
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		if _, _, err := client.Teams.GetTeamBySlug(context.Background(), org, value); err != nil {
+			return fmt.Errorf("team '%s' not found in '%s': %w", value, org, err)
+		}
+	}
+	return nil
+}
+
+// editorCommand returns the user's editor, split into command and arguments
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.Fields(os.Getenv(name)); len(editor) > 0 {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// completeConfigKeys provides autocomplete suggestions for config keys
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		// Only set takes a value after the key
+		key, err := internal.LookupConfigKey(args[0])
+		if err != nil || len(args) > 1 || cmd.Name() != "set" {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return key.Values, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, key := range internal.ConfigKeys {
+		names = append(names, key.Name+"\t"+key.Description)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProfiles provides autocomplete suggestions for profile names
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cfg, err := internal.LoadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return cfg.ProfileNames(), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lordzsolt/town/internal"
+	"github.com/lordzsolt/town/internal/cache"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffSince string
+	diffTeam  string
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show ownership changes between synced snapshots",
+	Long: `Compares the latest snapshot from 'town sync' with the one taken before
+--since, and reports repositories that gained or lost a team's ownership,
+new repositories without CODEOWNERS, repositories whose CODEOWNERS was
+removed, and teams whose footprint changed.
+
+Run 'town sync' regularly (e.g. daily in a scheduled job) to build up the
+history. Use --team to only report changes involving a single team.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if org == "" {
+			return fmt.Errorf("organization is required: use --org flag or set default_org in config")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		since, err := parseSince(diffSince)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+
+		from, to, err := loadDiffSnapshots(time.Now().Add(-since))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+
+		printDiff(internal.DiffSnapshots(from, to, diffTeam))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVar(&diffSince, "since", "7d", "How far back to compare, e.g. 24h, 7d or 4w")
+	diffCmd.Flags().StringVarP(&diffTeam, "team", "t", "", "Only show changes involving this team")
+
+	diffCmd.RegisterFlagCompletionFunc("team", completeTeamFlag)
+}
+
+// parseSince parses a duration, additionally accepting days (d) and weeks (w)
+func parseSince(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid duration '%s'", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s': use e.g. 24h, 7d or 4w", s)
+	}
+	return d, nil
+}
+
+// loadDiffSnapshots returns the newest snapshot taken at or before cutoff
+// (or the oldest one, if all are newer) and the latest snapshot.
+func loadDiffSnapshots(cutoff time.Time) (*cache.Snapshot, *cache.Snapshot, error) {
+	history, err := cache.ListSnapshotHistory(host, org)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read snapshot history: %w", err)
+	}
+	if len(history) < 2 {
+		return nil, nil, fmt.Errorf("need at least two snapshots of '%s' to compare: run 'town sync' regularly", org)
+	}
+
+	latest := history[len(history)-1]
+	baseline := history[0]
+	for _, entry := range history[:len(history)-1] {
+		if entry.Taken.After(cutoff) {
+			break
+		}
+		baseline = entry
+	}
+
+	if baseline.Taken.After(cutoff) {
+		fmt.Fprintf(os.Stderr, "Note: oldest snapshot is from %s, comparing against it\n\n", baseline.Taken.Local().Format(time.DateTime))
+	}
+
+	from, err := cache.LoadSnapshotFile(baseline.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+	to, err := cache.LoadSnapshotFile(latest.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+
+	return from, to, nil
+}
+
+func printDiff(diff *internal.SnapshotDiff) {
+	fmt.Printf("Ownership changes in '%s' between %s and %s:\n\n",
+		diff.To.Org, formatSyncedAt(diff.From), formatSyncedAt(diff.To))
+
+	fmt.Println("Repositories with changed ownership:")
+	for _, c := range diff.OwnershipChanges {
+		fmt.Printf("  %s\n", c.Repo)
+		for _, t := range c.Gained {
+			fmt.Printf("    + %s\n", t)
+		}
+		for _, t := range c.Lost {
+			fmt.Printf("    - %s\n", t)
+		}
+	}
+	if len(diff.OwnershipChanges) == 0 {
+		fmt.Println("  (none)")
+	}
+	fmt.Println()
+
+	if diffTeam == "" {
+		printDiffRepos("New repositories without CODEOWNERS:", diff.NewWithoutCodeowners)
+		printDiffRepos("Repositories whose CODEOWNERS was removed:", diff.LostCodeowners)
+	}
+
+	fmt.Println("Teams whose footprint changed:")
+	for _, c := range diff.FootprintChanges {
+		fmt.Printf("  %s: %d -> %d repositories (%+d)\n", c.Team, c.Before, c.After, c.After-c.Before)
+	}
+	if len(diff.FootprintChanges) == 0 {
+		fmt.Println("  (none)")
+	}
+}
+
+func printDiffRepos(title string, repos []string) {
+	fmt.Println(title)
+	for _, repo := range repos {
+		fmt.Printf("  %s\n", repo)
+	}
+	if len(repos) == 0 {
+		fmt.Println("  (none)")
+	}
+	fmt.Println()
+}
+
+func formatSyncedAt(snap *cache.Snapshot) string {
+	syncedAt, err := time.Parse(time.RFC3339, snap.SyncedAt)
+	if err != nil {
+		return snap.SyncedAt
+	}
+	return syncedAt.Local().Format(time.DateTime)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lordzsolt/town/internal"
+	"github.com/lordzsolt/town/internal/cache"
+	gh "github.com/lordzsolt/town/internal/github"
+	"github.com/lordzsolt/town/internal/paths"
+
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Show where town keeps its files and check the setup",
+	Long: `Shows the resolved config, cache and project config paths, the effective
+profile, host and organization, whether a snapshot was synced, and where the
+GitHub token comes from. Problems are marked with ✗.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ok := true
+		check := func(good bool, format string, a ...any) {
+			mark := "✓"
+			if !good {
+				mark = "✗"
+				ok = false
+			}
+			fmt.Printf("  %s %s\n", mark, fmt.Sprintf(format, a...))
+		}
+
+		fmt.Println("Paths:")
+		configDir, err := paths.ConfigDir()
+		check(err == nil, "Config directory: %s", orError(configDir, err))
+		configPath, err := internal.ConfigPath()
+		switch {
+		case err != nil:
+			check(false, "Config file: %v", err)
+		case internal.ConfigExists():
+			check(true, "Config file: %s", configPath)
+		default:
+			check(true, "Config file: none, would be created at %s", configPath)
+		}
+		if cfg.ProjectPath != "" {
+			check(true, "Project config: %s", cfg.ProjectPath)
+		} else {
+			check(true, "Project config: none")
+		}
+		cacheDir, err := cache.CacheDir(host)
+		check(err == nil, "Cache directory: %s", orError(cacheDir, err))
+		if legacyDir, err := paths.LegacyDir(); err == nil {
+			if entries, err := os.ReadDir(legacyDir); err == nil {
+				var names []string
+				for _, entry := range entries {
+					if entry.Name() != paths.MigratedMarker {
+						names = append(names, entry.Name())
+					}
+				}
+				if len(names) > 0 {
+					check(false, "Legacy directory %s still contains: %s (move or delete these files)", legacyDir, strings.Join(names, ", "))
+				}
+			}
+		}
+
+		fmt.Println("\nConfig:")
+		problems := append(cfg.Validate(), cfg.ValidateProject()...)
+		if len(problems) == 0 {
+			check(true, "No problems in config files")
+		}
+		for _, problem := range problems {
+			check(false, "%s", problem)
+		}
+		if name := cfg.ActiveProfile(profile); name != "" {
+			check(true, "Profile: %s", name)
+		} else {
+			check(true, "Profile: none")
+		}
+		check(true, "Host: %s", host)
+		check(org != "", "Organization: %s", orNone(org))
+
+		if org != "" {
+			snap, err := cache.LoadSnapshot(host, org)
+			switch {
+			case err != nil:
+				check(false, "Snapshot: %v", err)
+			case snap == nil:
+				check(true, "Snapshot: none, run 'town sync' to use --offline and 'town query'")
+			default:
+				syncedAt, _ := time.Parse(time.RFC3339, snap.SyncedAt)
+				check(true, "Snapshot: synced %s ago", time.Since(syncedAt).Round(time.Second))
+			}
+		}
+
+		fmt.Println("\nAuthentication:")
+		if usesApp() {
+			check(true, "GitHub App %d (key %s)", settings.AppID, settings.AppPrivateKey)
+		} else {
+			t, err := gh.LookupToken(tokenOptions())
+			switch {
+			case err != nil:
+				check(false, "Token: %v", err)
+			case t == nil:
+				check(false, "Token: not found, run 'town auth login'")
+			default:
+				check(true, "Token from %s ('town auth status' checks it)", t.Describe())
+			}
+		}
+
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func orError(value string, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return value
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
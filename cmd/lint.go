@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/lordzsolt/town/internal"
+	"github.com/lordzsolt/town/internal/cache"
+	"github.com/lordzsolt/town/internal/codeowners"
+	gh "github.com/lordzsolt/town/internal/github"
+
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Find invalid owners in CODEOWNERS files",
+	Long: `Parses the CODEOWNERS file of every repository and reports owners that
+GitHub silently ignores, with the file and line they are on:
+
+  - teams that don't exist in the organization (renamed or deleted)
+  - teams of another organization
+  - teams without write access to the repository
+  - users that aren't members of the organization (departed)
+
+Email owners can't be checked and are skipped. Exits with status 1 if any
+problem is found. With --offline, the snapshot created by 'town sync' is used.`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if org == "" {
+			return fmt.Errorf("organization is required: use --org flag or set default_org in config")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		var input *internal.LintInput
+		var snap *cache.Snapshot
+		if offline {
+			var err error
+			snap, err = loadSnapshot()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+			input = &internal.LintInput{
+				Org:        org,
+				Files:      snap.CodeownersFiles(),
+				Teams:      snap.TeamSlugs(),
+				OrgMembers: snap.OrgMembers,
+				TeamRepos:  snap.TeamRepos,
+			}
+		} else {
+			var err error
+			input, err = fetchLintInput()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+		}
+
+		problems := internal.LintCodeowners(input)
+		for _, p := range problems {
+			fmt.Printf("%s/%s:%d: %s: %s\n", p.Repo, p.Path, p.Line, p.Owner, p.Problem)
+		}
+
+		fmt.Printf("\nChecked %d CODEOWNERS files, found %d problems\n", len(input.Files), len(problems))
+		if snap != nil {
+			printSnapshotAge(snap)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
+
+// fetchLintInput collects the CODEOWNERS files, teams and members of the org
+// from the GitHub API. Permissions are only fetched for teams named in CODEOWNERS.
+func fetchLintInput() (*internal.LintInput, error) {
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	input := &internal.LintInput{Org: org, TeamRepos: make(map[string]map[string]string)}
+
+	teams, err := gh.FetchAllTeams(ctx, client, org)
+	if err != nil {
+		return nil, fmt.Errorf("fetching teams: %w", err)
+	}
+	known := make(map[string]bool, len(teams))
+	for _, t := range teams {
+		input.Teams = append(input.Teams, t.GetSlug())
+		known[t.GetSlug()] = true
+	}
+
+	if input.OrgMembers, err = gh.FetchOrgMembers(ctx, client, org); err != nil {
+		return nil, fmt.Errorf("fetching members: %w", err)
+	}
+
+	repos, err := gh.FetchAllRepos(ctx, client, org)
+	if err != nil {
+		return nil, fmt.Errorf("fetching repos: %w", err)
+	}
+	input.Files = gh.FetchCodeowners(ctx, client, org, repos)
+
+	for _, file := range input.Files {
+		for _, slug := range codeowners.Teams(codeowners.Parse(file.Content), org) {
+			if _, done := input.TeamRepos[slug]; done || !known[slug] {
+				continue
+			}
+			teamRepos, err := gh.FetchTeamRepos(ctx, client, org, slug)
+			if err != nil {
+				return nil, fmt.Errorf("fetching repos of %s: %w", slug, err)
+			}
+			perms := make(map[string]string, len(teamRepos))
+			for _, r := range teamRepos {
+				perms[r.GetName()] = gh.Permission(r)
+			}
+			input.TeamRepos[slug] = perms
+		}
+	}
+
+	return input, nil
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lordzsolt/town/internal"
+	"github.com/lordzsolt/town/internal/cache"
+	gh "github.com/lordzsolt/town/internal/github"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/spf13/cobra"
+)
+
+var matrixOutput string
+
+var matrixCmd = &cobra.Command{
+	Use:   "matrix",
+	Short: "Print a grid of teams versus the repositories they own",
+	Long: `Builds an ownership matrix from the CODEOWNERS files of every repository:
+one row per team named in CODEOWNERS and one column per repository. Each cell
+shows whether the team owns the whole repository ('*', for a rule like '*'),
+the specific paths it owns, or nothing.
+
+Repositories without team owners are included as empty columns.
+Use --output to print CSV, Markdown (default) or HTML, and redirect it to a
+file. With --offline, the snapshot created by 'town sync' is used.`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if org == "" {
+			return fmt.Errorf("organization is required: use --org flag or set default_org in config")
+		}
+		switch matrixOutput {
+		case "csv", "markdown", "html":
+			return nil
+		}
+		return fmt.Errorf("invalid output format '%s': use csv, markdown or html", matrixOutput)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		var repos []string
+		var files map[string]*cache.CodeownersFile
+		if offline {
+			snap, err := loadSnapshot()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+			repos, files = activeRepoNames(snap.Repos), snap.CodeownersFiles()
+			defer fprintSnapshotAge(os.Stderr, snap)
+		} else {
+			client, err := newClient()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+			ctx := context.Background()
+
+			all, err := gh.FetchAllRepos(ctx, client, org)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error fetching repos:", err)
+				os.Exit(1)
+			}
+			repos, files = activeRepoNames(all), gh.FetchCodeowners(ctx, client, org, all)
+		}
+
+		m := internal.BuildOwnershipMatrix(org, repos, files)
+
+		var err error
+		switch matrixOutput {
+		case "csv":
+			err = writeMatrixCSV(os.Stdout, m)
+		case "html":
+			err = writeMatrixHTML(os.Stdout, m)
+		default:
+			err = writeMatrixMarkdown(os.Stdout, m)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(matrixCmd)
+	matrixCmd.Flags().StringVar(&matrixOutput, "output", "markdown", "Output format: csv, markdown or html")
+	matrixCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{"csv", "markdown", "html"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// activeRepoNames returns the names of the repositories that aren't archived
+func activeRepoNames(repos []*github.Repository) []string {
+	var names []string
+	for _, r := range repos {
+		if !r.GetArchived() {
+			names = append(names, r.GetName())
+		}
+	}
+	return names
+}
+
+// matrixCellText describes a cell: "*" for the whole repository, otherwise
+// the patterns the team owns
+func matrixCellText(cell *internal.MatrixCell) string {
+	switch {
+	case cell == nil:
+		return ""
+	case cell.Whole:
+		return "*"
+	}
+	return strings.Join(cell.Patterns, " ")
+}
+
+func writeMatrixCSV(w io.Writer, m *internal.OwnershipMatrix) error {
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{"team"}, m.Repos...))
+	for _, team := range m.Teams {
+		record := []string{team}
+		for _, repo := range m.Repos {
+			record = append(record, matrixCellText(m.Cell(repo, team)))
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeMatrixMarkdown(w io.Writer, m *internal.OwnershipMatrix) error {
+	fmt.Fprintf(w, "| Team | %s |\n", strings.Join(m.Repos, " | "))
+	fmt.Fprintf(w, "|---|%s\n", strings.Repeat(":---:|", len(m.Repos)))
+	for _, team := range m.Teams {
+		fmt.Fprintf(w, "| %s |", team)
+		for _, repo := range m.Repos {
+			cell := m.Cell(repo, team)
+			switch {
+			case cell == nil:
+				fmt.Fprint(w, " |")
+			case cell.Whole:
+				fmt.Fprint(w, ` \* |`)
+			default:
+				// Code spans keep patterns like *.go from being read as emphasis
+				patterns := make([]string, len(cell.Patterns))
+				for i, p := range cell.Patterns {
+					patterns[i] = "`" + strings.ReplaceAll(p, "|", `\|`) + "`"
+				}
+				fmt.Fprintf(w, " %s |", strings.Join(patterns, "<br>"))
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	_, err := fmt.Fprint(w, "\n`*` owns the whole repository\n")
+	return err
+}
+
+func writeMatrixHTML(w io.Writer, m *internal.OwnershipMatrix) error {
+	fmt.Fprint(w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>`+html.EscapeString(org)+` ownership matrix</title>
+<style>
+table { border-collapse: collapse; font-family: sans-serif; font-size: 13px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; vertical-align: top; }
+thead th { position: sticky; top: 0; background: #f6f8fa; }
+td.whole { background: #2da44e; color: #fff; text-align: center; }
+td.paths { background: #dafbe1; font-family: monospace; }
+</style>
+</head>
+<body>
+<table>
+<thead>
+<tr><th>Team</th>`)
+	for _, repo := range m.Repos {
+		fmt.Fprintf(w, "<th>%s</th>", html.EscapeString(repo))
+	}
+	fmt.Fprint(w, "</tr>\n</thead>\n<tbody>\n")
+
+	for _, team := range m.Teams {
+		fmt.Fprintf(w, "<tr><th>%s</th>", html.EscapeString(team))
+		for _, repo := range m.Repos {
+			cell := m.Cell(repo, team)
+			switch {
+			case cell == nil:
+				fmt.Fprint(w, "<td></td>")
+			case cell.Whole:
+				fmt.Fprint(w, `<td class="whole" title="owns the whole repository">*</td>`)
+			default:
+				patterns := make([]string, len(cell.Patterns))
+				for i, p := range cell.Patterns {
+					patterns[i] = html.EscapeString(p)
+				}
+				fmt.Fprintf(w, `<td class="paths">%s</td>`, strings.Join(patterns, "<br>"))
+			}
+		}
+		fmt.Fprint(w, "</tr>\n")
+	}
+
+	_, err := fmt.Fprint(w, "</tbody>\n</table>\n</body>\n</html>\n")
+	return err
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lordzsolt/town/internal"
+	gh "github.com/lordzsolt/town/internal/github"
+	"github.com/lordzsolt/town/internal/picker"
+
+	"github.com/spf13/cobra"
+)
+
+var meCmd = &cobra.Command{
+	Use:   "me",
+	Short: "Show your teams and the repositories you own",
+	Long: `Like 'town whois', for the authenticated user: lists your teams in the
+organization, the repositories whose CODEOWNERS name you directly, and the
+repositories you own through your teams.`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if org == "" {
+			return fmt.Errorf("organization is required: use --org flag or set default_org in config")
+		}
+		if offline {
+			return fmt.Errorf("me needs the GitHub API to find out who you are: use 'town whois <login> --offline' instead")
+		}
+		if usesApp() {
+			return fmt.Errorf("me needs a user token, but a GitHub App is configured: use 'town whois <login>' instead")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := newClient()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		ctx := context.Background()
+
+		user, _, err := client.Users.Get(ctx, "")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error fetching the authenticated user:", err)
+			os.Exit(1)
+		}
+		login := user.GetLogin()
+
+		myTeams, err := gh.FetchMyTeams(ctx, client, org)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error fetching your teams:", err)
+			os.Exit(1)
+		}
+		roles, err := gh.FetchUserTeams(ctx, client, org, login, myTeams)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+
+		allTeams, err := gh.FetchAllTeams(ctx, client, org)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error fetching teams:", err)
+			os.Exit(1)
+		}
+		repos, err := gh.FetchAllRepos(ctx, client, org)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error fetching repos:", err)
+			os.Exit(1)
+		}
+		files := gh.FetchCodeowners(ctx, client, org, repos)
+
+		printUserOwnership(internal.ResolveUserOwnership(login, org, allTeams, roles, files))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(meCmd)
+}
+
+// detectDefaultTeam finds the team to use when --team and default_team are
+// both missing: the authenticated user's only team in the org, or the one
+// they pick, which is saved as default_team.
+func detectDefaultTeam() (string, error) {
+	slugs, err := myTeamSlugs()
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case len(slugs) == 0:
+		return "", fmt.Errorf("you are not in any team in '%s'", org)
+	case len(slugs) == 1:
+		fmt.Printf("Using your team '%s'. Set default_team in config to use another one.\n\n", slugs[0])
+		return slugs[0], nil
+	case !picker.IsInteractive():
+		return "", fmt.Errorf("you are in several teams in '%s': %s", org, strings.Join(slugs, ", "))
+	}
+
+	slug, err := picker.Pick(fmt.Sprintf("You are in several teams in '%s'. Which one do you want to use by default?", org), slugs)
+	if err != nil {
+		return "", err
+	}
+	return slug, saveDefaultTeam(slug)
+}
+
+// saveDefaultTeam saves a team the user picked as default_team, in the
+// active profile if there is one, so they aren't asked again
+func saveDefaultTeam(slug string) error {
+	key, err := internal.LookupConfigKey("default_team")
+	if err != nil {
+		return err
+	}
+	if err := key.Set(cfg, cfg.ActiveProfile(profile), slug); err != nil {
+		return err
+	}
+	if err := internal.SaveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save config: %v\n", err)
+	} else {
+		fmt.Printf("Saved '%s' as default_team. Change it with 'town config set default_team'.\n\n", slug)
+	}
+	return nil
+}
+
+// myTeamSlugs returns the sorted slugs of the authenticated user's teams in the org
+func myTeamSlugs() ([]string, error) {
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := gh.FetchMyTeams(context.Background(), client, org)
+	if err != nil {
+		return nil, fmt.Errorf("fetching your teams: %w", err)
+	}
+
+	slugs := make([]string, len(teams))
+	for i, t := range teams {
+		slugs[i] = t.GetSlug()
+	}
+	sort.Strings(slugs)
+	return slugs, nil
+}
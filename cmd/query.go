@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lordzsolt/town/internal/cache"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	queryOutput string
+	querySchema bool
+)
+
+var queryCmd = &cobra.Command{
+	Use:   `query "<SQL>"`,
+	Short: "Run SQL against the local ownership index",
+	Long: `Runs an ad-hoc, read-only SQL query against the SQLite ownership index
+built by 'town sync'. Use --schema to print the available tables.
+
+Examples:
+  # Teams owning more than 20 repositories
+  town query "SELECT team_slug, COUNT(DISTINCT r.repo) AS repos
+              FROM rule_owners o JOIN codeowners_rules r ON r.id = o.rule_id
+              WHERE team_slug IS NOT NULL
+              GROUP BY team_slug HAVING repos > 20"
+
+  # CODEOWNERS rules referencing teams that no longer exist
+  town query "SELECT r.repo, r.line, o.owner
+              FROM rule_owners o JOIN codeowners_rules r ON r.id = o.rule_id
+              WHERE o.kind = 'team' AND o.team_slug NOT IN (SELECT slug FROM teams)"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if querySchema {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if org == "" {
+			return fmt.Errorf("organization is required: use --org flag or set default_org in config")
+		}
+		if queryOutput == "" {
+			queryOutput = settings.Output
+		}
+		switch queryOutput {
+		case "":
+			queryOutput = "table"
+			return nil
+		case "table", "csv", "json":
+			return nil
+		}
+		return fmt.Errorf("invalid output format '%s': use table, csv or json", queryOutput)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if querySchema {
+			fmt.Println(strings.TrimSpace(cache.IndexSchema()))
+			return
+		}
+
+		db, err := cache.OpenIndex(host, org)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		rows, err := db.Query(args[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error running query:", err)
+			os.Exit(1)
+		}
+		defer rows.Close()
+
+		if err := printRows(rows, queryOutput); err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading results:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+	queryCmd.Flags().StringVar(&queryOutput, "output", "", "Output format: table, csv or json (default table, or output in config)")
+	queryCmd.Flags().BoolVar(&querySchema, "schema", false, "Print the schema of the index")
+}
+
+// printRows writes query results to stdout in the given format
+func printRows(rows *sql.Rows, format string) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	var records [][]string
+	var objects []map[string]any
+
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+
+		record := make([]string, len(columns))
+		object := make(map[string]any, len(columns))
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			if v != nil {
+				record[i] = fmt.Sprint(v)
+			}
+			object[columns[i]] = v
+		}
+		records = append(records, record)
+		objects = append(objects, object)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	switch format {
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write(columns)
+		w.WriteAll(records)
+		return w.Error()
+	case "json":
+		if objects == nil {
+			objects = []map[string]any{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(objects)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(columns, "\t"))
+	for _, record := range records {
+		fmt.Fprintln(w, strings.Join(record, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d rows\n", len(records))
+	return nil
+}
@@ -2,24 +2,32 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"runtime"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/lordzsolt/town/internal"
 	"github.com/lordzsolt/town/internal/cache"
+	"github.com/lordzsolt/town/internal/fuzzy"
 	gh "github.com/lordzsolt/town/internal/github"
+	"github.com/lordzsolt/town/internal/picker"
 
 	"github.com/google/go-github/v58/github"
 	"github.com/spf13/cobra"
 )
 
 var (
-	team     string
-	noOwner  bool
-	clone    bool
-	cloneDir string
+	team             string
+	includeChildren  bool
+	noOwner          bool
+	clone            bool
+	cloneDir         string
+	reposInteractive bool
 )
 
 var reposCmd = &cobra.Command{
@@ -28,10 +36,22 @@ var reposCmd = &cobra.Command{
 	Long: `Searches all repositories in the organization. By default, returns those
 where the specified team is mentioned in the CODEOWNERS file.
 
+Use --include-children to also find repositories owned by any team nested
+below the team.
 Use --no-owner to find repositories without a CODEOWNERS file.
 Use --clone to clone all matching repositories.
+Use --interactive to choose which of the matching repositories to clone or
+open in the browser.
 
-Results are cached for 1 hour to avoid unnecessary API calls.`,
+Without --team and default_team, you pick one of the cached teams in an
+interactive terminal, with your own teams first, and it is saved as default_team.
+Otherwise, your own team is used.
+
+Shell completion of --team fuzzy-matches team names in fish. Bash, zsh and
+PowerShell only complete teams starting with the typed text.
+
+Results are cached for 1 hour to avoid unnecessary API calls.
+With --offline, repositories are searched in the snapshot created by 'town sync'.`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		if org == "" {
 			return fmt.Errorf("organization is required: use --org flag or set defaultOrg in config")
@@ -43,26 +63,37 @@ Results are cached for 1 hour to avoid unnecessary API calls.`,
 		}
 
 		// Apply config default for team if not provided
-		if team == "" && cfg != nil {
-			team = cfg.DefaultTeam
-		}
-
-		if team == "" {
-			return fmt.Errorf("team is required: use --team flag or set defaultTeam in config (or use --no-owner)")
+		if team == "" && settings != nil {
+			team = settings.DefaultTeam
 		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
+		if !noOwner && team == "" {
+			resolved, err := resolveTeam()
+			if errors.Is(err, picker.ErrCanceled) {
+				os.Exit(1)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error: team is required: use --team flag or set defaultTeam in config (or use --no-owner):", err)
+				os.Exit(1)
+			}
+			team = resolved
+		}
+
+		if offline {
+			runReposOffline()
+			return
+		}
+
 		// Check if we have a valid cached result
-		if cached := cache.GetValidCache(org, team, noOwner); cached != nil {
+		if cached := cache.GetValidCache(host, org, team, includeChildren, noOwner); cached != nil {
 			printCachedResult(cached)
-			if clone {
-				internal.CloneReposFromCache(cached.Repos, cloneDir)
-			}
+			handleRepos(cached.Repos)
 			return
 		}
 
-		client, err := gh.NewClient()
+		client, err := newClient()
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "Error:", err)
 			os.Exit(1)
@@ -74,7 +105,13 @@ Results are cached for 1 hour to avoid unnecessary API calls.`,
 		if noOwner {
 			repos, err = gh.FetchReposWithoutCodeowners(ctx, client, org)
 		} else {
-			repos, err = gh.FetchReposWithTeamInCodeowners(ctx, client, org, team)
+			var teams []string
+			teams, err = searchedTeams(func() ([]*github.Team, error) {
+				return gh.FetchAllTeams(ctx, client, org)
+			})
+			if err == nil {
+				repos, err = gh.FetchReposWithTeamInCodeowners(ctx, client, org, teams)
+			}
 		}
 
 		if err != nil {
@@ -82,60 +119,239 @@ Results are cached for 1 hour to avoid unnecessary API calls.`,
 			os.Exit(1)
 		}
 
-		cache.CacheResult(org, team, noOwner, repos)
+		cache.CacheResult(host, org, team, includeChildren, noOwner, repos)
 
-		if clone {
-			internal.CloneRepos(repos, cloneDir)
-		}
+		handleRepos(cache.NewCachedRepos(repos))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(reposCmd)
 	reposCmd.Flags().StringVarP(&team, "team", "t", "", "Team name to search for in CODEOWNERS")
+	reposCmd.Flags().BoolVar(&includeChildren, "include-children", false, "Also find repositories owned by the team's child teams")
 	reposCmd.Flags().BoolVar(&noOwner, "no-owner", false, "List repositories without a CODEOWNERS file")
 	reposCmd.Flags().BoolVar(&clone, "clone", false, "Clone all matching repositories")
-	reposCmd.Flags().StringVar(&cloneDir, "clone-dir", ".", "Directory to clone repositories into")
+	reposCmd.Flags().BoolVarP(&reposInteractive, "interactive", "i", false, "Choose which matching repositories to clone or open")
+	reposCmd.Flags().StringVar(&cloneDir, "clone-dir", "", "Directory to clone repositories into (default: clone_dir from config, or the current directory)")
 
 	// Register completion for --team flag using cached teams
 	reposCmd.RegisterFlagCompletionFunc("team", completeTeamFlag)
 }
 
-// completeTeamFlag provides autocomplete suggestions for the --team flag
-func completeTeamFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	// Determine org: check flag first, then config
-	completionOrg, _ := cmd.Flags().GetString("org")
-	if completionOrg == "" {
-		// Try loading config for default org
-		cfg, err := internal.LoadConfig()
-		if err == nil && cfg.DefaultOrg != "" {
-			completionOrg = cfg.DefaultOrg
+// cloneOptions combines the --clone-dir flag with the clone settings from config
+func cloneOptions() internal.CloneOptions {
+	dir := cloneDir
+	if dir == "" {
+		dir = settings.CloneDir
+	}
+	return internal.CloneOptions{
+		Dir:      dir,
+		Layout:   settings.CloneLayout,
+		Protocol: settings.CloneProtocol,
+		Host:     host,
+		Org:      org,
+	}
+}
+
+// searchedTeams returns the teams to search CODEOWNERS for: --team, and its
+// descendants with --include-children. The org's teams are only listed if needed.
+func searchedTeams(listTeams func() ([]*github.Team, error)) ([]string, error) {
+	if !includeChildren {
+		return []string{team}, nil
+	}
+
+	teams, err := listTeams()
+	if err != nil {
+		return nil, fmt.Errorf("fetching teams: %w", err)
+	}
+	// --team may differ in case from the slug the hierarchy is keyed by
+	slug := team
+	for _, t := range teams {
+		if strings.EqualFold(t.GetSlug(), team) {
+			slug = t.GetSlug()
+			break
 		}
 	}
+	return append([]string{slug}, gh.TeamDescendants(teams, slug)...), nil
+}
 
-	if completionOrg == "" {
-		// Can't complete without knowing the org
-		return nil, cobra.ShellCompDirectiveNoFileComp
+// runReposOffline answers the repos command from the org snapshot
+func runReposOffline() {
+	snap, err := loadSnapshot()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
 	}
 
-	// Load cached teams for this org
-	teams, err := cache.LoadCachedTeams(completionOrg)
+	var repos []*github.Repository
+	if noOwner {
+		repos = gh.FilterReposWithoutCodeowners(snap)
+	} else {
+		teams, _ := searchedTeams(func() ([]*github.Team, error) { return snap.Teams, nil })
+		repos = gh.FilterReposWithTeamInCodeowners(snap, teams)
+	}
+
+	printSnapshotAge(snap)
+
+	handleRepos(cache.NewCachedRepos(repos))
+}
+
+// resolveTeam finds the team to search for when --team and default_team are
+// both missing. In a terminal, the user picks one of the cached teams, with
+// their own teams first, and the choice is saved as default_team. Otherwise,
+// their team is detected.
+func resolveTeam() (string, error) {
+	canDetect := !offline && !usesApp()
+
+	if picker.IsInteractive() {
+		if slugs := loadTeamSlugs(host, org); len(slugs) > 0 {
+			var mine []string
+			if canDetect {
+				mine, _ = myTeamSlugs() // Only used for ranking
+			}
+			slug, err := picker.Pick("Select your default team", ownTeamsFirst(slugs, mine))
+			if err != nil {
+				return "", err
+			}
+			return slug, saveDefaultTeam(slug)
+		}
+	}
+
+	switch {
+	case offline:
+		return "", errors.New("can't detect your team offline")
+	case usesApp():
+		return "", errors.New("can't detect your team when authenticating as a GitHub App")
+	}
+	return detectDefaultTeam()
+}
+
+// ownTeamsFirst moves the user's own teams to the front of slugs, keeping
+// the order otherwise
+func ownTeamsFirst(slugs, mine []string) []string {
+	ordered := make([]string, 0, len(slugs))
+	var others []string
+	for _, slug := range slugs {
+		if slices.Contains(mine, slug) {
+			ordered = append(ordered, slug)
+		} else {
+			others = append(others, slug)
+		}
+	}
+	return append(ordered, others...)
+}
+
+// loadTeamSlugs returns the cached teams of org on host, falling back to the
+// offline snapshot. Returns nil if neither exists.
+func loadTeamSlugs(host, org string) []string {
+	teams, err := cache.LoadCachedTeams(host, org)
 	if err != nil || teams == nil {
-		return nil, cobra.ShellCompDirectiveNoFileComp
+		snap, err := cache.LoadSnapshot(host, org)
+		if err != nil || snap == nil {
+			return nil
+		}
+		teams = snap.TeamSlugs()
 	}
+	return teams
+}
+
+// handleRepos clones the matching repositories with --clone, or lets the
+// user choose which ones to clone or open with --interactive
+func handleRepos(repos []*cache.CachedRepo) {
+	if reposInteractive && len(repos) > 0 {
+		chooseRepos(repos)
+		return
+	}
+	if clone {
+		internal.CloneReposFromCache(repos, cloneOptions())
+	}
+}
 
-	// Filter teams by prefix if user has started typing
-	if toComplete != "" {
-		var filtered []string
-		for _, t := range teams {
-			if strings.HasPrefix(t, toComplete) {
-				filtered = append(filtered, t)
+// chooseRepos asks which repositories to clone or open in the browser
+func chooseRepos(repos []*cache.CachedRepo) {
+	byName := make(map[string]*cache.CachedRepo, len(repos))
+	names := make([]string, len(repos))
+	for i, r := range repos {
+		byName[r.Name] = r
+		names[i] = r.Name
+	}
+
+	chosen, err := picker.PickMany("Select repositories", names)
+	if errors.Is(err, picker.ErrCanceled) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	selected := make([]*cache.CachedRepo, len(chosen))
+	for i, name := range chosen {
+		selected[i] = byName[name]
+	}
+
+	action := "Clone"
+	if !clone {
+		action, err = picker.Pick(fmt.Sprintf("What do you want to do with %d repositories?", len(selected)), []string{"Clone", "Open in browser"})
+		if errors.Is(err, picker.ErrCanceled) {
+			return
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+	}
+
+	if action == "Clone" {
+		internal.CloneReposFromCache(selected, cloneOptions())
+		return
+	}
+	for _, r := range selected {
+		if err := openBrowser(r.HTMLURL); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open %s: %v\n", r.HTMLURL, err)
+		}
+	}
+}
+
+// openBrowser opens url in the user's default browser
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
+
+// completeTeamFlag provides autocomplete suggestions for the --team flag
+func completeTeamFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// Determine org and host: check flags first, then config
+	completionOrg, _ := cmd.Flags().GetString("org")
+	completionHost, _ := cmd.Flags().GetString("host")
+	completionProfile, _ := cmd.Flags().GetString("profile")
+	if cfg, err := internal.LoadConfig(); err == nil {
+		if s, err := cfg.Resolve(completionProfile); err == nil {
+			if completionOrg == "" {
+				completionOrg = s.DefaultOrg
+			}
+			if completionHost == "" {
+				completionHost = s.Host
 			}
 		}
-		return filtered, cobra.ShellCompDirectiveNoFileComp
 	}
 
-	return teams, cobra.ShellCompDirectiveNoFileComp
+	if completionOrg == "" {
+		// Can't complete without knowing the org
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	teams := loadTeamSlugs(gh.NormalizeHost(completionHost), completionOrg)
+
+	// Rank teams by how well they match what the user typed. Only fish shows
+	// matches like "team-payments" for "pay": bash, zsh and PowerShell drop
+	// candidates that don't start with the typed text.
+	return fuzzy.Rank(toComplete, teams), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
 }
 
 // printCachedResult prints the cached repos result
@@ -146,7 +362,11 @@ func printCachedResult(cached *cache.ReposResult) {
 	if cached.NoOwner {
 		fmt.Println("Repositories without CODEOWNERS:")
 	} else {
-		fmt.Printf("Repositories where '%s' is mentioned in CODEOWNERS:\n", cached.Team)
+		if cached.IncludeChildren {
+			fmt.Printf("Repositories where '%s' or one of its child teams is mentioned in CODEOWNERS:\n", cached.Team)
+		} else {
+			fmt.Printf("Repositories where '%s' is mentioned in CODEOWNERS:\n", cached.Team)
+		}
 	}
 	fmt.Println()
 
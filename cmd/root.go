@@ -5,13 +5,23 @@ import (
 	"os"
 
 	"github.com/lordzsolt/town/internal"
+	"github.com/lordzsolt/town/internal/cache"
+	gh "github.com/lordzsolt/town/internal/github"
+	"github.com/lordzsolt/town/internal/paths"
 
+	"github.com/google/go-github/v58/github"
 	"github.com/spf13/cobra"
 )
 
 var (
-	org string
-	cfg *internal.Config
+	org     string
+	host    string
+	token   string
+	profile string
+	offline bool
+	cfg     *internal.Config
+	// settings are the config settings with the selected profile applied
+	settings *internal.Settings
 )
 
 var rootCmd = &cobra.Command{
@@ -20,11 +30,19 @@ var rootCmd = &cobra.Command{
 	Long: `town is a CLI tool that helps you explore GitHub organizations,
 their teams, and find repositories owned by specific teams via CODEOWNERS.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Move files left in ~/.town by earlier versions to the XDG directories
+		moves, err := paths.MigrateLegacy(internal.ConfigFileNames())
+		for _, move := range moves {
+			fmt.Fprintf(os.Stderr, "Moved %s to %s\n", move.From, move.To)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not move files out of ~/.town: %v\n", err)
+		}
+
 		// Check if config exists before loading
 		configExists := internal.ConfigExists()
 
 		// Load config
-		var err error
 		cfg, err = internal.LoadConfig()
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
@@ -40,10 +58,30 @@ their teams, and find repositories owned by specific teams via CODEOWNERS.`,
 			}
 		}
 
+		// Report typos and invalid values instead of silently ignoring them
+		if problems := cfg.Validate(); len(problems) > 0 {
+			configPath, _ := internal.ConfigPath()
+			for _, problem := range problems {
+				fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", configPath, problem)
+			}
+		}
+		for _, problem := range cfg.ValidateProject() {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", cfg.ProjectPath, problem)
+		}
+
+		settings, err = cfg.Resolve(profile)
+		if err != nil {
+			return err
+		}
+
 		// Apply config defaults if flags not provided
 		if org == "" {
-			org = cfg.DefaultOrg
+			org = settings.DefaultOrg
+		}
+		if host == "" {
+			host = settings.Host
 		}
+		host = gh.NormalizeHost(host)
 
 		return nil
 	},
@@ -66,6 +104,48 @@ func Command() *cobra.Command {
 	return rootCmd
 }
 
+// newClient creates a GitHub client authenticated according to the global flags
+// and config. A GitHub App configured in the config is used unless --token is given.
+func newClient() (*github.Client, error) {
+	opts := tokenOptions()
+	if usesApp() {
+		opts.App = &gh.AppCredentials{
+			AppID:          settings.AppID,
+			PrivateKeyPath: settings.AppPrivateKey,
+			InstallationID: settings.AppInstallationID,
+		}
+	}
+	return gh.NewClient(opts)
+}
+
+// usesApp reports whether commands authenticate as the GitHub App configured
+// in the config. Installation tokens don't belong to a user.
+func usesApp() bool {
+	return settings != nil && settings.AppID != 0 && token == ""
+}
+
+// loadSnapshot returns the offline snapshot of the current org.
+// Returns an error if the org was never synced.
+func loadSnapshot() (*cache.Snapshot, error) {
+	snap, err := cache.LoadSnapshot(host, org)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+	if snap == nil {
+		return nil, fmt.Errorf("no offline snapshot for '%s': run 'town sync' while online first", org)
+	}
+	return snap, nil
+}
+
+// tokenOptions returns the options used to look up the token for the current host and org
+func tokenOptions() gh.ClientOptions {
+	return gh.ClientOptions{Token: token, TokenSource: settings.TokenSource, Host: host, Org: org}
+}
+
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&org, "org", "o", "", "GitHub organization name")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Named config profile to use (default: current_profile from config)")
+	rootCmd.PersistentFlags().StringVar(&host, "host", "", "GitHub Enterprise Server hostname (default github.com)")
+	rootCmd.PersistentFlags().StringVar(&token, "token", "", "GitHub token to use instead of the environment, gh CLI or keyring")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Answer from the local snapshot created by 'town sync' instead of the GitHub API")
 }
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lordzsolt/town/internal"
+	gh "github.com/lordzsolt/town/internal/github"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsTeam   string
+	statsRecent int
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show how much each team owns",
+	Long: `Reports the footprint of every team named in CODEOWNERS, to spot
+overloaded teams when rebalancing ownership:
+
+  - the number of repositories and CODEOWNERS rules (paths) naming the team
+  - the total size of the repositories it touches, as reported by GitHub:
+    a repository counts in full even if the team owns a single path of it,
+    as GitHub reports neither lines of code nor sizes by path
+  - their primary languages
+  - the ones pushed to most recently
+  - how many of them are co-owned with other teams
+
+Teams owning the most repositories come first. Archived repositories are
+skipped. With --offline, the snapshot created by 'town sync' is used.`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if org == "" {
+			return fmt.Errorf("organization is required: use --org flag or set default_org in config")
+		}
+		if statsRecent < 0 {
+			return fmt.Errorf("--recent must not be negative")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if offline {
+			snap, err := loadSnapshot()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+			printTeamStats(internal.ComputeTeamStats(org, snap.Repos, snap.CodeownersFiles(), statsRecent))
+			printSnapshotAge(snap)
+			return
+		}
+
+		client, err := newClient()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		ctx := context.Background()
+
+		repos, err := gh.FetchAllRepos(ctx, client, org)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error fetching repos:", err)
+			os.Exit(1)
+		}
+		files := gh.FetchCodeowners(ctx, client, org, repos)
+
+		printTeamStats(internal.ComputeTeamStats(org, repos, files, statsRecent))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().StringVarP(&statsTeam, "team", "t", "", "Only show the stats of this team")
+	statsCmd.Flags().IntVar(&statsRecent, "recent", 3, "Number of most recently pushed repositories to show per team")
+	statsCmd.RegisterFlagCompletionFunc("team", completeTeamFlag)
+}
+
+// printTeamStats prints the stats of every team, or only --team's
+func printTeamStats(stats []*internal.TeamStats) {
+	shown := 0
+	for _, s := range stats {
+		if statsTeam != "" && !strings.EqualFold(s.Team, statsTeam) {
+			continue
+		}
+		shown++
+
+		fmt.Println(s.Team)
+		fmt.Printf("  Repositories:  %d (%d co-owned with other teams)\n", s.Repos, s.CoOwned)
+		fmt.Printf("  Paths:         %d CODEOWNERS rules\n", s.Paths)
+		fmt.Printf("  Size touched:  %s\n", formatSize(s.SizeKB))
+
+		var languages []string
+		for _, l := range s.Languages {
+			languages = append(languages, fmt.Sprintf("%s (%d)", l.Language, l.Repos))
+		}
+		fmt.Printf("  Languages:     %s\n", orNone(strings.Join(languages, ", ")))
+
+		if statsRecent > 0 {
+			fmt.Printf("  Last pushed:   %s\n", orNone(describePushed(s.RecentlyPushed)))
+		}
+		fmt.Println()
+	}
+
+	if statsTeam == "" {
+		fmt.Printf("%d teams named in CODEOWNERS\n", shown)
+	} else if shown == 0 {
+		fmt.Printf("'%s' isn't named in any CODEOWNERS file\n", statsTeam)
+	}
+}
+
+// describePushed lists repositories with the date they were last pushed to
+func describePushed(repos []*github.Repository) string {
+	var parts []string
+	for _, r := range repos {
+		if r.PushedAt == nil {
+			parts = append(parts, r.GetName())
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", r.GetName(), r.GetPushedAt().Format("2006-01-02")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatSize formats a size in kilobytes, e.g. "12.3 MB"
+func formatSize(kb int) string {
+	switch {
+	case kb < 1024:
+		return fmt.Sprintf("%d KB", kb)
+	case kb < 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(kb)/1024)
+	}
+	return fmt.Sprintf("%.1f GB", float64(kb)/(1024*1024))
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/lordzsolt/town/internal/cache"
+	gh "github.com/lordzsolt/town/internal/github"
+
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Download a snapshot of the organization for offline use",
+	Long: `Downloads repository metadata, teams, memberships, team permissions and
+every CODEOWNERS file of the organization into the local cache.
+
+Afterwards, pass --offline to answer commands from the snapshot without
+talking to the GitHub API, or use 'town query' to run SQL against the
+ownership index built from it. Run sync again to refresh the snapshot.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if org == "" {
+			return fmt.Errorf("organization is required: use --org flag or set default_org in config")
+		}
+		if offline {
+			return fmt.Errorf("sync needs GitHub API access and cannot be used with --offline")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := newClient()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+
+		ctx := context.Background()
+		snap, err := gh.FetchSnapshot(ctx, client, org)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error syncing organization:", err)
+			os.Exit(1)
+		}
+
+		if err := cache.SaveSnapshot(host, snap); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving snapshot:", err)
+			os.Exit(1)
+		}
+
+		if err := cache.BuildIndex(host, snap); err != nil {
+			fmt.Fprintln(os.Stderr, "Error building index:", err)
+			os.Exit(1)
+		}
+
+		// Keep the completion cache in line with the snapshot
+		if err := cache.CacheTeams(host, org, snap.TeamSlugs()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to cache teams: %v\n", err)
+		}
+
+		fmt.Printf("\nSynced '%s': %d repositories, %d teams, %d CODEOWNERS files\n",
+			org, len(snap.Repos), len(snap.Teams), len(snap.Codeowners))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+}
+
+// printSnapshotAge tells the user how old the answer from a snapshot is
+func printSnapshotAge(snap *cache.Snapshot) {
+	fprintSnapshotAge(os.Stdout, snap)
+}
+
+// fprintSnapshotAge is printSnapshotAge writing to w, e.g. stderr for
+// commands generating files
+func fprintSnapshotAge(w io.Writer, snap *cache.Snapshot) {
+	syncedAt, _ := time.Parse(time.RFC3339, snap.SyncedAt)
+	age := time.Since(syncedAt).Round(time.Second)
+	fmt.Fprintf(w, "Answered from offline snapshot synced %s ago\n", age)
+}
@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lordzsolt/town/internal"
+	"github.com/lordzsolt/town/internal/cache"
+	"github.com/lordzsolt/town/internal/codeowners"
+	gh "github.com/lordzsolt/town/internal/github"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/spf13/cobra"
+)
+
+// teamDetails is everything 'town team show' prints about a team
+type teamDetails struct {
+	Team        *github.Team
+	Children    []string
+	Maintainers []string
+	Members     []string
+	// Repos maps the repositories the team has explicit permissions on to the permission
+	Repos      map[string]string
+	Codeowners []*internal.CodeownersMatch
+}
+
+var teamCmd = &cobra.Command{
+	Use:   "team",
+	Short: "Show details of a single team",
+}
+
+var teamShowCmd = &cobra.Command{
+	Use:   "show <slug>",
+	Short: "Show a team's members, maintainers and the repositories it owns",
+	Long: `Shows a team's description, privacy, parent and child teams, its
+maintainers and members, the repositories it has explicit permissions on, and
+the repositories whose CODEOWNERS name it.
+
+Finding the CODEOWNERS mentions reads every repository's CODEOWNERS file.
+With --offline, everything is read from the snapshot created by 'town sync'.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTeamArg,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if org == "" {
+			return fmt.Errorf("organization is required: use --org flag or set default_org in config")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// Accept the @org/team form used in CODEOWNERS, whatever the org's case
+		slug := strings.TrimPrefix(args[0], "@")
+		if teamSlug, ok := codeowners.TeamSlug("@"+slug, org); ok {
+			slug = teamSlug
+		}
+
+		var details *teamDetails
+		var err error
+		if offline {
+			var snap *cache.Snapshot
+			snap, err = loadSnapshot()
+			if err == nil {
+				details, err = teamDetailsFromSnapshot(snap, slug)
+				defer printSnapshotAge(snap)
+			}
+		} else {
+			details, err = fetchTeamDetails(slug)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+
+		printTeamDetails(details)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(teamCmd)
+	teamCmd.AddCommand(teamShowCmd)
+}
+
+// completeTeamArg completes a team slug as the first argument
+func completeTeamArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeTeamFlag(cmd, args, toComplete)
+}
+
+// fetchTeamDetails collects a team's details from the GitHub API
+func fetchTeamDetails(slug string) (*teamDetails, error) {
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	t, resp, err := client.Teams.GetTeamBySlug(ctx, org, slug)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("team '%s' not found in '%s'", slug, org)
+		}
+		return nil, fmt.Errorf("fetching team: %w", err)
+	}
+	slug = t.GetSlug()
+	details := &teamDetails{Team: t, Repos: make(map[string]string)}
+
+	children, err := gh.FetchChildTeams(ctx, client, org, slug)
+	if err != nil {
+		return nil, fmt.Errorf("fetching child teams: %w", err)
+	}
+	for _, child := range children {
+		details.Children = append(details.Children, child.GetSlug())
+	}
+
+	if details.Maintainers, err = fetchTeamLogins(ctx, client, slug, "maintainer"); err != nil {
+		return nil, err
+	}
+	if details.Members, err = fetchTeamLogins(ctx, client, slug, "member"); err != nil {
+		return nil, err
+	}
+
+	repos, err := gh.FetchTeamRepos(ctx, client, org, slug)
+	if err != nil {
+		return nil, fmt.Errorf("fetching team repos: %w", err)
+	}
+	for _, r := range repos {
+		details.Repos[r.GetName()] = gh.Permission(r)
+	}
+
+	allRepos, err := gh.FetchAllRepos(ctx, client, org)
+	if err != nil {
+		return nil, fmt.Errorf("fetching repos: %w", err)
+	}
+	files := gh.FetchCodeowners(ctx, client, org, allRepos)
+	details.Codeowners = internal.FindTeamOwnership(files, org, []string{slug})
+
+	return details, nil
+}
+
+func fetchTeamLogins(ctx context.Context, client *github.Client, slug, role string) ([]string, error) {
+	users, err := gh.FetchTeamMembers(ctx, client, org, slug, role)
+	if err != nil {
+		return nil, fmt.Errorf("fetching team members: %w", err)
+	}
+	logins := make([]string, len(users))
+	for i, u := range users {
+		logins[i] = u.GetLogin()
+	}
+	sort.Strings(logins)
+	return logins, nil
+}
+
+// teamDetailsFromSnapshot collects a team's details from the offline snapshot
+func teamDetailsFromSnapshot(snap *cache.Snapshot, slug string) (*teamDetails, error) {
+	t := snap.Team(slug)
+	if t == nil {
+		return nil, fmt.Errorf("team '%s' not found in the snapshot of '%s'", slug, org)
+	}
+	slug = t.GetSlug()
+
+	details := &teamDetails{Team: t, Repos: snap.TeamRepos[slug]}
+	for _, other := range snap.Teams {
+		if other.GetParent().GetSlug() == slug {
+			details.Children = append(details.Children, other.GetSlug())
+		}
+	}
+	sort.Strings(details.Children)
+
+	for _, m := range snap.Members[slug] {
+		if m.Role == "maintainer" {
+			details.Maintainers = append(details.Maintainers, m.Login)
+		} else {
+			details.Members = append(details.Members, m.Login)
+		}
+	}
+	sort.Strings(details.Maintainers)
+	sort.Strings(details.Members)
+
+	details.Codeowners = internal.FindTeamOwnership(snap.CodeownersFiles(), org, []string{slug})
+	return details, nil
+}
+
+func printTeamDetails(d *teamDetails) {
+	t := d.Team
+	fmt.Printf("%s (%s)\n", t.GetName(), t.GetSlug())
+	if desc := t.GetDescription(); desc != "" {
+		fmt.Println(desc)
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Privacy:\t%s\n", orNone(t.GetPrivacy()))
+	fmt.Fprintf(w, "Parent:\t%s\n", orNone(t.GetParent().GetSlug()))
+	fmt.Fprintf(w, "Child teams:\t%s\n", orNone(strings.Join(d.Children, ", ")))
+	if url := t.GetHTMLURL(); url != "" {
+		fmt.Fprintf(w, "URL:\t%s\n", url)
+	}
+	w.Flush()
+
+	printLogins("Maintainers", d.Maintainers)
+	printLogins("Members", d.Members)
+
+	fmt.Printf("\nRepositories with explicit permissions (%d):\n", len(d.Repos))
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	names := make([]string, 0, len(d.Repos))
+	for name := range d.Repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\t%s\n", name, d.Repos[name])
+	}
+	w.Flush()
+
+	fmt.Printf("\nRepositories naming @%s/%s in CODEOWNERS (%d):\n", org, t.GetSlug(), len(d.Codeowners))
+	printCodeownersMatches(d.Codeowners)
+}
+
+func printLogins(title string, logins []string) {
+	fmt.Printf("\n%s (%d):\n", title, len(logins))
+	for _, login := range logins {
+		fmt.Printf("  %s\n", login)
+	}
+}
+
+// printCodeownersMatches prints repositories with the CODEOWNERS patterns matching an owner
+func printCodeownersMatches(matches []*internal.CodeownersMatch) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, m := range matches {
+		fmt.Fprintf(w, "  %s\t%s\n", m.Repo, strings.Join(m.Patterns, " "))
+	}
+	w.Flush()
+}
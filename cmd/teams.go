@@ -6,15 +6,30 @@ import (
 	"os"
 
 	"github.com/lordzsolt/town/internal/cache"
+	"github.com/lordzsolt/town/internal/fuzzy"
 	gh "github.com/lordzsolt/town/internal/github"
 
+	"github.com/google/go-github/v58/github"
 	"github.com/spf13/cobra"
 )
 
+var (
+	teamsTree   bool
+	teamsSearch string
+)
+
 var teamsCmd = &cobra.Command{
 	Use:   "teams",
 	Short: "List all teams in an organization",
-	Long:  `Fetches and displays all teams in the specified GitHub organization.`,
+	Long: `Fetches and displays all teams in the specified GitHub organization.
+
+With --tree, teams are shown nested below their parent team, with the
+number of members and repositories of each team.
+
+With --search, only teams whose slug, name or description fuzzy-match the
+query are shown, best match first.
+
+With --offline, teams are read from the snapshot created by 'town sync'.`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		if org == "" {
 			return fmt.Errorf("organization is required: use --org flag or set default_org in config")
@@ -22,7 +37,26 @@ var teamsCmd = &cobra.Command{
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		client, err := gh.NewClient()
+		if offline {
+			snap, err := loadSnapshot()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+			if teamsTree {
+				counts := make(map[string]gh.TeamCounts, len(snap.Teams))
+				for _, slug := range snap.TeamSlugs() {
+					counts[slug] = gh.TeamCounts{Members: len(snap.Members[slug]), Repos: len(snap.TeamRepos[slug])}
+				}
+				gh.PrintTeamTree(gh.BuildTeamTree(snap.Teams), org, counts)
+			} else {
+				gh.PrintTeams(searchTeams(snap.Teams), org)
+			}
+			printSnapshotAge(snap)
+			return
+		}
+
+		client, err := newClient()
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "Error:", err)
 			os.Exit(1)
@@ -40,14 +74,38 @@ var teamsCmd = &cobra.Command{
 		for i, team := range teams {
 			teamNames[i] = team.GetSlug()
 		}
-		if err := cache.CacheTeams(org, teamNames); err != nil {
+		if err := cache.CacheTeams(host, org, teamNames); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to cache teams: %v\n", err)
 		}
 
-		gh.PrintTeams(teams, org)
+		if !teamsTree {
+			gh.PrintTeams(searchTeams(teams), org)
+			return
+		}
+
+		counts, err := gh.FetchTeamCounts(ctx, client, org, teams)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error fetching teams:", err)
+			os.Exit(1)
+		}
+		gh.PrintTeamTree(gh.BuildTeamTree(teams), org, counts)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(teamsCmd)
+	teamsCmd.Flags().BoolVar(&teamsTree, "tree", false, "Show the team hierarchy with member and repository counts")
+	teamsCmd.Flags().StringVarP(&teamsSearch, "search", "s", "", "Only show teams fuzzy-matching the query")
+	teamsCmd.MarkFlagsMutuallyExclusive("tree", "search")
+}
+
+// searchTeams returns the teams matching --search, best match first,
+// or all teams if no search was given
+func searchTeams(teams []*github.Team) []*github.Team {
+	if teamsSearch == "" {
+		return teams
+	}
+	return fuzzy.RankFunc(teamsSearch, teams, func(t *github.Team) []string {
+		return []string{t.GetSlug(), t.GetName(), t.GetDescription()}
+	})
 }
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lordzsolt/town/internal"
+	"github.com/lordzsolt/town/internal/cache"
+	gh "github.com/lordzsolt/town/internal/github"
+
+	"github.com/spf13/cobra"
+)
+
+var whoisCmd = &cobra.Command{
+	Use:   "whois <@user>",
+	Short: "Show a user's teams and the repositories they own",
+	Long: `Lists the teams a GitHub user belongs to, including parent teams of
+their teams, the repositories whose CODEOWNERS name the user directly, and
+the repositories owned through their teams.
+
+Useful when onboarding someone, or to find what needs a new owner when
+someone leaves. Checking every team membership and CODEOWNERS file takes a
+while; with --offline, the snapshot created by 'town sync' is used instead.`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if org == "" {
+			return fmt.Errorf("organization is required: use --org flag or set default_org in config")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		login := strings.TrimPrefix(args[0], "@")
+
+		var ownership *internal.UserOwnership
+		var orgMember bool
+		var err error
+		if offline {
+			var snap *cache.Snapshot
+			snap, err = loadSnapshot()
+			if err == nil {
+				ownership, orgMember = userOwnershipFromSnapshot(snap, login)
+				defer printSnapshotAge(snap)
+			}
+		} else {
+			ownership, orgMember, err = fetchUserOwnership(login)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+
+		if !orgMember {
+			fmt.Printf("Note: %s is not a member of '%s'.\n\n", ownership.Login, org)
+		}
+		printUserOwnership(ownership)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(whoisCmd)
+}
+
+// fetchUserOwnership looks up a user's teams and ownership through the GitHub API.
+// Also reports whether the user is a member of the org.
+func fetchUserOwnership(login string) (*internal.UserOwnership, bool, error) {
+	client, err := newClient()
+	if err != nil {
+		return nil, false, err
+	}
+	ctx := context.Background()
+
+	user, resp, err := client.Users.Get(ctx, login)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, false, fmt.Errorf("user '%s' not found", login)
+		}
+		return nil, false, fmt.Errorf("fetching user: %w", err)
+	}
+	login = user.GetLogin() // Correct the case
+
+	orgMember, _, err := client.Organizations.IsMember(ctx, org, login)
+	if err != nil {
+		return nil, false, fmt.Errorf("checking org membership: %w", err)
+	}
+
+	teams, err := gh.FetchAllTeams(ctx, client, org)
+	if err != nil {
+		return nil, false, fmt.Errorf("fetching teams: %w", err)
+	}
+	fmt.Printf("Checking %s's membership in %d teams...\n", login, len(teams))
+	roles, err := gh.FetchUserTeams(ctx, client, org, login, teams)
+	if err != nil {
+		return nil, false, err
+	}
+
+	repos, err := gh.FetchAllRepos(ctx, client, org)
+	if err != nil {
+		return nil, false, fmt.Errorf("fetching repos: %w", err)
+	}
+	files := gh.FetchCodeowners(ctx, client, org, repos)
+	fmt.Println()
+
+	return internal.ResolveUserOwnership(login, org, teams, roles, files), orgMember, nil
+}
+
+// userOwnershipFromSnapshot looks up a user's teams and ownership in the offline snapshot
+func userOwnershipFromSnapshot(snap *cache.Snapshot, login string) (*internal.UserOwnership, bool) {
+	orgMember := false
+	for _, m := range snap.OrgMembers {
+		if strings.EqualFold(m, login) {
+			login = m // Correct the case
+			orgMember = true
+		}
+	}
+
+	roles := make(map[string]string)
+	for slug, members := range snap.Members {
+		for _, m := range members {
+			if strings.EqualFold(m.Login, login) {
+				roles[slug] = m.Role
+			}
+		}
+	}
+
+	return internal.ResolveUserOwnership(login, org, snap.Teams, roles, snap.CodeownersFiles()), orgMember
+}
+
+func printUserOwnership(o *internal.UserOwnership) {
+	fmt.Printf("Teams of %s in '%s' (%d):\n", o.Login, org, len(o.Teams))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, t := range o.Teams {
+		if t.Via != "" {
+			fmt.Fprintf(w, "  %s\t%s (via %s)\n", t.Slug, t.Role, t.Via)
+		} else {
+			fmt.Fprintf(w, "  %s\t%s\n", t.Slug, t.Role)
+		}
+	}
+	w.Flush()
+
+	fmt.Printf("\nRepositories naming @%s in CODEOWNERS (%d):\n", o.Login, len(o.Direct))
+	printCodeownersMatches(o.Direct)
+
+	fmt.Printf("\nRepositories owned through teams (%d):\n", len(o.ThroughTeams))
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, r := range o.ThroughTeams {
+		fmt.Fprintf(w, "  %s\t%s\n", r.Repo, strings.Join(r.Teams, ", "))
+	}
+	w.Flush()
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"sort"
+
+	"github.com/lordzsolt/town/internal/cache"
+)
+
+// writePermissions are the repository permissions that allow approving pull requests
+var writePermissions = map[string]bool{"admin": true, "maintain": true, "push": true}
+
+// PermissionFinding is a repository whose permissions don't match its CODEOWNERS
+type PermissionFinding struct {
+	Repo string
+	// Permission is the team's permission on the repository, "" if it has none
+	Permission string
+	// Patterns are the CODEOWNERS patterns naming the team, if any
+	Patterns []string
+}
+
+// PermissionAudit compares a team's repository permissions with its CODEOWNERS ownership
+type PermissionAudit struct {
+	Team string
+	// OwnedWithoutWrite are repositories whose CODEOWNERS name the team, but
+	// where the team can't approve pull requests
+	OwnedWithoutWrite []*PermissionFinding
+	// AdminWithoutOwnership are repositories the team administers without
+	// being named in CODEOWNERS
+	AdminWithoutOwnership []*PermissionFinding
+	// Consistent is the number of owned repositories the team can write to
+	Consistent int
+}
+
+// AuditPermissions checks the team's permissions (by repository) against the
+// CODEOWNERS files (by repository) of the org
+func AuditPermissions(org, team string, files map[string]*cache.CodeownersFile, permissions map[string]string) *PermissionAudit {
+	audit := &PermissionAudit{Team: team}
+
+	owned := make(map[string]bool)
+	for _, m := range FindTeamOwnership(files, org, []string{team}) {
+		owned[m.Repo] = true
+		perm := permissions[m.Repo]
+		if writePermissions[perm] {
+			audit.Consistent++
+			continue
+		}
+		audit.OwnedWithoutWrite = append(audit.OwnedWithoutWrite, &PermissionFinding{Repo: m.Repo, Permission: perm, Patterns: m.Patterns})
+	}
+
+	for repo, perm := range permissions {
+		if perm == "admin" && !owned[repo] {
+			audit.AdminWithoutOwnership = append(audit.AdminWithoutOwnership, &PermissionFinding{Repo: repo, Permission: perm})
+		}
+	}
+	sort.Slice(audit.AdminWithoutOwnership, func(i, j int) bool {
+		return audit.AdminWithoutOwnership[i].Repo < audit.AdminWithoutOwnership[j].Repo
+	})
+
+	return audit
+}
@@ -1,28 +1,25 @@
 package cache
 
 import (
-	"os"
 	"path/filepath"
-)
-
-const appName = "town"
 
-// GetCacheDir returns the cache directory following XDG Base Directory Specification.
-// Priority:
-//  1. $XDG_CACHE_HOME/town
-//  2. ~/.town/cache/ (fallback)
-func getCacheDir() (string, error) {
-	cacheHome := os.Getenv("XDG_CACHE_HOME")
-	if cacheHome != "" {
-		return filepath.Join(cacheHome, appName), nil
-	}
+	"github.com/lordzsolt/town/internal/paths"
+)
 
-	home, err := os.UserHomeDir()
+// CacheDir returns the cache directory of a GitHub host following XDG Base
+// Directory Specification: $XDG_CACHE_HOME/town, where $XDG_CACHE_HOME
+// defaults to ~/.cache.
+//
+// github.com data is stored directly in the cache dir. For other hosts, the
+// host name is appended, so github.com and GitHub Enterprise Server orgs with
+// the same name don't overwrite each other.
+func CacheDir(host string) (string, error) {
+	dir, err := paths.CacheDir()
 	if err != nil {
 		return "", err
 	}
-
-	// Check if ~/.town exists (legacy), use it if so
-	legacyDir := filepath.Join(home, "."+appName)
-	return filepath.Join(legacyDir, "cache"), nil
+	if host != "github.com" {
+		dir = filepath.Join(dir, host)
+	}
+	return dir, nil
 }
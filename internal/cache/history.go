@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	historyDirName = "history"
+
+	// historyTimeFormat is used in history file names so they sort chronologically
+	historyTimeFormat = "20060102T150405Z"
+
+	// maxHistory is the number of files of each kind kept in the history
+	maxHistory = 100
+)
+
+// HistoryEntry is a timestamped file in an org's history
+type HistoryEntry struct {
+	Path  string
+	Taken time.Time
+}
+
+// archive writes data as <cache_dir>/<org>/history/<kind>-<timestamp>.json
+// and prunes the oldest files of that kind beyond maxHistory.
+func archive(host, org, kind string, taken time.Time, data []byte) error {
+	cacheDir, err := CacheDir(host)
+	if err != nil {
+		return err
+	}
+
+	historyDir := filepath.Join(cacheDir, org, historyDirName)
+	if err := os.MkdirAll(historyDir, 0755); err != nil {
+		return err
+	}
+
+	name := kind + "-" + taken.UTC().Format(historyTimeFormat) + ".json"
+	if err := os.WriteFile(filepath.Join(historyDir, name), data, 0644); err != nil {
+		return err
+	}
+
+	entries, err := listHistory(host, org, kind)
+	if err != nil {
+		return err
+	}
+	for len(entries) > maxHistory {
+		if err := os.Remove(entries[0].Path); err != nil {
+			return err
+		}
+		entries = entries[1:]
+	}
+
+	return nil
+}
+
+// listHistory returns the history files of a kind, oldest first
+func listHistory(host, org, kind string) ([]*HistoryEntry, error) {
+	cacheDir, err := CacheDir(host)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := os.ReadDir(filepath.Join(cacheDir, org, historyDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []*HistoryEntry
+	for _, f := range files {
+		stamp, ok := strings.CutPrefix(f.Name(), kind+"-")
+		if !ok {
+			continue
+		}
+		taken, err := time.Parse(historyTimeFormat, strings.TrimSuffix(stamp, ".json"))
+		if err != nil {
+			continue // Not one of ours
+		}
+		entries = append(entries, &HistoryEntry{
+			Path:  filepath.Join(cacheDir, org, historyDirName, f.Name()),
+			Taken: taken,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Taken.Before(entries[j].Taken)
+	})
+
+	return entries, nil
+}
+
+// ListSnapshotHistory returns the archived snapshots of an org, oldest first.
+func ListSnapshotHistory(host, org string) ([]*HistoryEntry, error) {
+	return listHistory(host, org, "snapshot")
+}
+
+// LoadSnapshotFile reads a snapshot from the given path.
+func LoadSnapshotFile(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+
+	return &snap, nil
+}
@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lordzsolt/town/internal/codeowners"
+
+	_ "modernc.org/sqlite"
+)
+
+const indexFileName = "index.db"
+
+// indexSchema describes the tables available to `town query`
+const indexSchema = `
+CREATE TABLE repos (
+	name            TEXT PRIMARY KEY,
+	full_name       TEXT,
+	description     TEXT,
+	language        TEXT,
+	private         INTEGER,
+	archived        INTEGER,
+	size_kb         INTEGER,
+	stars           INTEGER,
+	pushed_at       TEXT,
+	html_url        TEXT,
+	codeowners_path TEXT
+);
+CREATE TABLE teams (
+	slug        TEXT PRIMARY KEY,
+	name        TEXT,
+	description TEXT,
+	privacy     TEXT,
+	parent_slug TEXT
+);
+CREATE TABLE org_members (
+	login TEXT PRIMARY KEY
+);
+CREATE TABLE team_members (
+	team_slug TEXT,
+	login     TEXT,
+	role      TEXT
+);
+CREATE TABLE team_repos (
+	team_slug  TEXT,
+	repo       TEXT,
+	permission TEXT
+);
+CREATE TABLE codeowners_rules (
+	id      INTEGER PRIMARY KEY,
+	repo    TEXT,
+	line    INTEGER,
+	pattern TEXT
+);
+CREATE TABLE rule_owners (
+	rule_id   INTEGER REFERENCES codeowners_rules(id),
+	owner     TEXT,
+	kind      TEXT,
+	team_slug TEXT
+);
+CREATE INDEX rule_owners_team ON rule_owners(team_slug);
+CREATE INDEX team_members_login ON team_members(login);
+`
+
+// IndexSchema returns the SQL schema of the ownership index.
+func IndexSchema() string {
+	return indexSchema
+}
+
+// BuildIndex (re)creates the SQLite ownership index of the snapshot's org.
+// File is stored as <cache_dir>/<org>/index.db
+func BuildIndex(host string, snap *Snapshot) error {
+	path, err := GetIndexPath(host, snap.Org)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// The index is derived data, so rebuild from scratch
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(indexSchema); err != nil {
+		return fmt.Errorf("creating schema: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertSnapshot(tx, snap); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertSnapshot(tx *sql.Tx, snap *Snapshot) error {
+	for _, r := range snap.Repos {
+		var codeownersPath string
+		if f, ok := snap.Codeowners[r.GetName()]; ok && f != nil {
+			codeownersPath = f.Path
+		}
+		var pushedAt string
+		if r.PushedAt != nil {
+			pushedAt = r.PushedAt.Format(time.RFC3339)
+		}
+		_, err := tx.Exec(`INSERT INTO repos VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			r.GetName(), r.GetFullName(), r.GetDescription(), r.GetLanguage(),
+			r.GetPrivate(), r.GetArchived(), r.GetSize(), r.GetStargazersCount(),
+			pushedAt, r.GetHTMLURL(), codeownersPath)
+		if err != nil {
+			return fmt.Errorf("indexing repo %s: %w", r.GetName(), err)
+		}
+	}
+
+	for _, t := range snap.Teams {
+		var parent string
+		if t.Parent != nil {
+			parent = t.Parent.GetSlug()
+		}
+		_, err := tx.Exec(`INSERT INTO teams VALUES (?, ?, ?, ?, ?)`,
+			t.GetSlug(), t.GetName(), t.GetDescription(), t.GetPrivacy(), parent)
+		if err != nil {
+			return fmt.Errorf("indexing team %s: %w", t.GetSlug(), err)
+		}
+	}
+
+	for _, login := range snap.OrgMembers {
+		if _, err := tx.Exec(`INSERT INTO org_members VALUES (?)`, login); err != nil {
+			return fmt.Errorf("indexing member %s: %w", login, err)
+		}
+	}
+
+	for slug, members := range snap.Members {
+		for _, m := range members {
+			if _, err := tx.Exec(`INSERT INTO team_members VALUES (?, ?, ?)`, slug, m.Login, m.Role); err != nil {
+				return fmt.Errorf("indexing members of %s: %w", slug, err)
+			}
+		}
+	}
+
+	for slug, repos := range snap.TeamRepos {
+		for repo, perm := range repos {
+			if _, err := tx.Exec(`INSERT INTO team_repos VALUES (?, ?, ?)`, slug, repo, perm); err != nil {
+				return fmt.Errorf("indexing repos of %s: %w", slug, err)
+			}
+		}
+	}
+
+	for repo, file := range snap.Codeowners {
+		for _, rule := range codeowners.Parse(file.Content) {
+			res, err := tx.Exec(`INSERT INTO codeowners_rules (repo, line, pattern) VALUES (?, ?, ?)`,
+				repo, rule.Line, rule.Pattern)
+			if err != nil {
+				return fmt.Errorf("indexing CODEOWNERS of %s: %w", repo, err)
+			}
+			ruleID, err := res.LastInsertId()
+			if err != nil {
+				return err
+			}
+
+			for _, owner := range rule.Owners {
+				var teamSlug sql.NullString
+				if slug, ok := codeowners.TeamSlug(owner, snap.Org); ok {
+					teamSlug = sql.NullString{String: slug, Valid: true}
+				}
+				_, err := tx.Exec(`INSERT INTO rule_owners VALUES (?, ?, ?, ?)`,
+					ruleID, owner, codeowners.OwnerKind(owner), teamSlug)
+				if err != nil {
+					return fmt.Errorf("indexing CODEOWNERS of %s: %w", repo, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// OpenIndex opens the SQLite ownership index of an org.
+// Returns an error if the index hasn't been built yet.
+func OpenIndex(host, org string) (*sql.DB, error) {
+	path, err := GetIndexPath(host, org)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("no index found: run 'town sync' first")
+		}
+		return nil, err
+	}
+
+	return sql.Open("sqlite", "file:"+path+"?mode=ro")
+}
+
+// GetIndexPath returns the path to the SQLite index for an org.
+func GetIndexPath(host, org string) (string, error) {
+	cacheDir, err := CacheDir(host)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, org, indexFileName), nil
+}
@@ -13,51 +13,60 @@ const cacheTTL = 1 * time.Hour
 
 // ReposResult represents the cached result of a repos command run
 type ReposResult struct {
-	Org       string        `json:"org"`
-	Team      string        `json:"team,omitempty"`
-	NoOwner   bool          `json:"noOwner,omitempty"`
-	Repos     []*CachedRepo `json:"repos"`
-	RunAt     string        `json:"runAt"`
-	CachePath string        `json:"cachePath"`
+	Org  string `json:"org"`
+	Team string `json:"team,omitempty"`
+	// IncludeChildren is set if the team's child teams were searched for too
+	IncludeChildren bool          `json:"includeChildren,omitempty"`
+	NoOwner         bool          `json:"noOwner,omitempty"`
+	Repos           []*CachedRepo `json:"repos"`
+	RunAt           string        `json:"runAt"`
+	CachePath       string        `json:"cachePath"`
 }
 
 type CachedRepo struct {
 	Name     string `json:"name"`
 	HTMLURL  string `json:"html_url"`
 	CloneURL string `json:"clone_url"`
+	SSHURL   string `json:"ssh_url,omitempty"`
 }
 
-// cacheReposResult saves the repos result to cache
-func CacheResult(org, team string, noOwner bool, repos []*github.Repository) error {
+// NewCachedRepos converts repositories to their cached form
+func NewCachedRepos(repos []*github.Repository) []*CachedRepo {
 	cachedRepos := make([]*CachedRepo, len(repos))
 	for i, r := range repos {
 		cachedRepos[i] = &CachedRepo{
 			Name:     r.GetName(),
 			HTMLURL:  r.GetHTMLURL(),
 			CloneURL: r.GetCloneURL(),
+			SSHURL:   r.GetSSHURL(),
 		}
 	}
+	return cachedRepos
+}
 
+// cacheReposResult saves the repos result to cache
+func CacheResult(host, org, team string, includeChildren, noOwner bool, repos []*github.Repository) error {
 	result := &ReposResult{
-		Org:     org,
-		Team:    team,
-		NoOwner: noOwner,
-		Repos:   cachedRepos,
-		RunAt:   time.Now().Format(time.RFC3339),
+		Org:             org,
+		Team:            team,
+		IncludeChildren: includeChildren,
+		NoOwner:         noOwner,
+		Repos:           NewCachedRepos(repos),
+		RunAt:           time.Now().Format(time.RFC3339),
 	}
 
-	return cacheReposResult(result)
+	return cacheReposResult(host, result)
 }
 
 // getValidCache returns the cached result if it matches the parameters and is less than 15 minutes old
-func GetValidCache(org, team string, noOwner bool) *ReposResult {
-	cached, err := loadCachedReposResult(org)
+func GetValidCache(host, org, team string, includeChildren, noOwner bool) *ReposResult {
+	cached, err := loadCachedReposResult(host, org)
 	if err != nil || cached == nil {
 		return nil
 	}
 
 	// Check if parameters match
-	if cached.Team != team || cached.NoOwner != noOwner {
+	if cached.Team != team || cached.IncludeChildren != includeChildren || cached.NoOwner != noOwner {
 		return nil
 	}
 
@@ -75,9 +84,9 @@ func GetValidCache(org, team string, noOwner bool) *ReposResult {
 }
 
 // CacheReposResult stores the result of a repos command run.
-// File is stored as <cache_dir>/<org>/repos-last.json
-func cacheReposResult(result *ReposResult) error {
-	cacheDir, err := getCacheDir()
+// File is stored as <cache_dir>/<org>/repos-last.json, replacing the previous result.
+func cacheReposResult(host string, result *ReposResult) error {
+	cacheDir, err := CacheDir(host)
 	if err != nil {
 		return err
 	}
@@ -100,8 +109,8 @@ func cacheReposResult(result *ReposResult) error {
 
 // LoadCachedReposResult reads the last repos command result from cache.
 // Returns nil, nil if no cache exists.
-func loadCachedReposResult(org string) (*ReposResult, error) {
-	cacheDir, err := getCacheDir()
+func loadCachedReposResult(host, org string) (*ReposResult, error) {
+	cacheDir, err := CacheDir(host)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v58/github"
+)
+
+const snapshotFileName = "snapshot.json"
+
+// Snapshot is a full copy of an organization's ownership data, written by
+// `town sync` and used to answer commands when running with --offline.
+type Snapshot struct {
+	Org        string                       `json:"org"`
+	SyncedAt   string                       `json:"syncedAt"`
+	Repos      []*github.Repository         `json:"repos"`
+	Teams      []*github.Team               `json:"teams"`
+	OrgMembers []string                     `json:"orgMembers"`
+	Members    map[string][]*TeamMember     `json:"members"`    // team slug -> members
+	TeamRepos  map[string]map[string]string `json:"teamRepos"`  // team slug -> repo name -> permission
+	Codeowners map[string]*CodeownersFile   `json:"codeowners"` // repo name -> CODEOWNERS file
+}
+
+// TeamMember is a single member of a team together with their role
+// ("member" or "maintainer").
+type TeamMember struct {
+	Login string `json:"login"`
+	Role  string `json:"role"`
+}
+
+// CodeownersFile is the CODEOWNERS file found in a repository.
+type CodeownersFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// TeamSlugs returns the slugs of all teams in the snapshot.
+func (s *Snapshot) TeamSlugs() []string {
+	slugs := make([]string, len(s.Teams))
+	for i, t := range s.Teams {
+		slugs[i] = t.GetSlug()
+	}
+	return slugs
+}
+
+// CodeownersContent returns the CODEOWNERS content of a repository,
+// or "" if the repository has none.
+func (s *Snapshot) CodeownersContent(repo string) string {
+	if f, ok := s.Codeowners[repo]; ok && f != nil {
+		return f.Content
+	}
+	return ""
+}
+
+// CodeownersFiles returns the CODEOWNERS file of every repository that has
+// one, by repository name. Archived repositories are left out.
+func (s *Snapshot) CodeownersFiles() map[string]*CodeownersFile {
+	files := make(map[string]*CodeownersFile)
+	for _, r := range s.Repos {
+		if f := s.Codeowners[r.GetName()]; f != nil && f.Content != "" && !r.GetArchived() {
+			files[r.GetName()] = f
+		}
+	}
+	return files
+}
+
+// Team returns the team with the given slug, or nil if there is none.
+func (s *Snapshot) Team(slug string) *github.Team {
+	for _, t := range s.Teams {
+		if strings.EqualFold(t.GetSlug(), slug) {
+			return t
+		}
+	}
+	return nil
+}
+
+// SaveSnapshot stores the snapshot for its organization.
+// File is stored as <cache_dir>/<org>/snapshot.json, with a timestamped
+// copy kept in <cache_dir>/<org>/history/ for `town diff`.
+func SaveSnapshot(host string, snap *Snapshot) error {
+	cacheDir, err := CacheDir(host)
+	if err != nil {
+		return err
+	}
+
+	orgCacheDir := filepath.Join(cacheDir, snap.Org)
+	if err := os.MkdirAll(orgCacheDir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(orgCacheDir, snapshotFileName), data, 0644); err != nil {
+		return err
+	}
+
+	syncedAt, err := time.Parse(time.RFC3339, snap.SyncedAt)
+	if err != nil {
+		syncedAt = time.Now()
+	}
+	return archive(host, snap.Org, "snapshot", syncedAt, data)
+}
+
+// LoadSnapshot reads the snapshot of an organization.
+// Returns nil, nil if no snapshot exists.
+func LoadSnapshot(host, org string) (*Snapshot, error) {
+	cacheDir, err := CacheDir(host)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := LoadSnapshotFile(filepath.Join(cacheDir, org, snapshotFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return snap, nil
+}
@@ -11,8 +11,8 @@ const teamsFileName = "teams"
 
 // CacheTeams stores team names to the cache file, one per line.
 // The file is stored as <cache_dir>/<org>/teams
-func CacheTeams(org string, teamNames []string) error {
-	cacheDir, err := getCacheDir()
+func CacheTeams(host, org string, teamNames []string) error {
+	cacheDir, err := CacheDir(host)
 	if err != nil {
 		return err
 	}
@@ -40,8 +40,8 @@ func CacheTeams(org string, teamNames []string) error {
 
 // LoadCachedTeams reads team names from the cache file.
 // Returns nil, nil if the cache file doesn't exist.
-func LoadCachedTeams(org string) ([]string, error) {
-	cacheDir, err := getCacheDir()
+func LoadCachedTeams(host, org string) ([]string, error) {
+	cacheDir, err := CacheDir(host)
 	if err != nil {
 		return nil, err
 	}
@@ -73,8 +73,8 @@ func LoadCachedTeams(org string) ([]string, error) {
 }
 
 // GetTeamsCachePath returns the path to the teams cache file for an org.
-func GetTeamsCachePath(org string) (string, error) {
-	cacheDir, err := getCacheDir()
+func GetTeamsCachePath(host, org string) (string, error) {
+	cacheDir, err := CacheDir(host)
 	if err != nil {
 		return "", err
 	}
@@ -5,67 +5,113 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/lordzsolt/town/internal/cache"
+)
+
+// CloneOptions configures where and how repositories are cloned
+type CloneOptions struct {
+	Dir string
+	// Layout is flat (<dir>/<repo>), org (<dir>/<org>/<repo>)
+	// or host (<dir>/<host>/<org>/<repo>). Empty means flat.
+	Layout string
+	// Protocol is https or ssh. Empty means https.
+	Protocol string
+
+	Host string
+	Org  string
+}
 
-	"github.com/google/go-github/v58/github"
+// CloneLayouts and CloneProtocols list the valid CloneOptions values
+var (
+	CloneLayouts   = []string{"flat", "org", "host"}
+	CloneProtocols = []string{"https", "ssh"}
 )
 
-// CloneRepos clones the given repositories
-func CloneRepos(repos []*github.Repository, cloneDir string) {
+// CloneReposFromCache clones repositories from cached results
+func CloneReposFromCache(repos []*cache.CachedRepo, opts CloneOptions) {
 	if len(repos) == 0 {
 		return
 	}
 
-	fmt.Printf("\nCloning %d repositories to %s...\n\n", len(repos), cloneDir)
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+	baseDir, err := opts.baseDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return
+	}
+
+	fmt.Printf("\nCloning %d repositories to %s...\n\n", len(repos), baseDir)
 
 	// Ensure clone directory exists
-	if err := os.MkdirAll(cloneDir, 0755); err != nil {
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating clone directory: %v\n", err)
 		return
 	}
 
-	var cloned, failed int
+	var cloned, skipped, failed int
 	for _, repo := range repos {
-		var repoName = repo.GetName()
-		err := cloneRepo(repoName, repo.GetCloneURL(), cloneDir)
+		url, err := opts.cloneURL(repo)
+		if err == nil {
+			err = cloneRepo(repo.Name, url, baseDir)
+		}
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to clone %s: %v\n", repoName, err)
+			fmt.Fprintf(os.Stderr, "Failed to clone %s: %v\n", repo.Name, err)
 			failed++
 		} else {
 			cloned++
 		}
 	}
 
-	fmt.Printf("\nClone complete: %d cloned, %d failed\n", cloned, failed)
+	fmt.Printf("\nClone complete: %d cloned, %d skipped, %d failed\n", cloned, skipped, failed)
 }
 
-// CloneReposFromCache clones repositories from cached results
-func CloneReposFromCache(repos []*cache.CachedRepo, cloneDir string) {
-	if len(repos) == 0 {
-		return
+// baseDir returns the directory repositories are cloned into, based on the layout
+func (o CloneOptions) baseDir() (string, error) {
+	// Expand ~ from config files, shells only do it for flags
+	if rest, ok := strings.CutPrefix(o.Dir, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		o.Dir = filepath.Join(home, rest)
 	}
 
-	fmt.Printf("\nCloning %d repositories to %s...\n\n", len(repos), cloneDir)
-
-	// Ensure clone directory exists
-	if err := os.MkdirAll(cloneDir, 0755); err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating clone directory: %v\n", err)
-		return
+	switch o.Layout {
+	case "", "flat":
+		return o.Dir, nil
+	case "org":
+		return filepath.Join(o.Dir, o.Org), nil
+	case "host":
+		host := o.Host
+		if host == "" {
+			host = "github.com"
+		}
+		return filepath.Join(o.Dir, host, o.Org), nil
 	}
+	return "", fmt.Errorf("invalid clone layout '%s': use flat, org or host", o.Layout)
+}
 
-	var cloned, skipped, failed int
-	for _, repo := range repos {
-		err := cloneRepo(repo.Name, repo.CloneURL, cloneDir)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to clone %s: %v\n", repo.Name, err)
-			failed++
-		} else {
-			cloned++
+// cloneURL returns the URL to clone repo with, based on the protocol
+func (o CloneOptions) cloneURL(repo *cache.CachedRepo) (string, error) {
+	switch o.Protocol {
+	case "", "https":
+		return repo.CloneURL, nil
+	case "ssh":
+		if repo.SSHURL != "" {
+			return repo.SSHURL, nil
+		}
+		// Results cached by earlier versions don't have the SSH URL
+		host := o.Host
+		if host == "" {
+			host = "github.com"
 		}
+		return fmt.Sprintf("git@%s:%s/%s.git", host, o.Org, repo.Name), nil
 	}
-
-	fmt.Printf("\nClone complete: %d cloned, %d skipped, %d failed\n", cloned, skipped, failed)
+	return "", fmt.Errorf("invalid clone protocol '%s': use https or ssh", o.Protocol)
 }
 
 func cloneRepo(name string, url string, cloneDir string) error {
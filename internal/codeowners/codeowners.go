@@ -0,0 +1,109 @@
+package codeowners
+
+import (
+	"sort"
+	"strings"
+)
+
+// Rule is a single non-empty, non-comment line of a CODEOWNERS file
+type Rule struct {
+	Line    int
+	Pattern string
+	Owners  []string
+}
+
+// Parse splits CODEOWNERS content into rules, keeping their line numbers.
+// Comments, blank lines and trailing comments are ignored.
+func Parse(content string) []*Rule {
+	var rules []*Rule
+
+	for i, line := range strings.Split(content, "\n") {
+		// Strip trailing comments. An escaped \# is part of a pattern.
+		if idx := commentIndex(line); idx >= 0 {
+			line = line[:idx]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		rules = append(rules, &Rule{
+			Line:    i + 1,
+			Pattern: fields[0],
+			Owners:  fields[1:],
+		})
+	}
+
+	return rules
+}
+
+func commentIndex(line string) int {
+	for i := 0; i < len(line); i++ {
+		if line[i] == '#' && (i == 0 || line[i-1] != '\\') {
+			return i
+		}
+	}
+	return -1
+}
+
+// OwnerKind classifies an owner as "team", "user" or "email"
+func OwnerKind(owner string) string {
+	switch {
+	case strings.HasPrefix(owner, "@") && strings.Contains(owner, "/"):
+		return "team"
+	case strings.HasPrefix(owner, "@"):
+		return "user"
+	default:
+		return "email"
+	}
+}
+
+// TeamSlug returns the team slug of an @org/team owner if it belongs to org.
+// The comparison is case-insensitive, like GitHub's.
+func TeamSlug(owner, org string) (string, bool) {
+	if OwnerKind(owner) != "team" {
+		return "", false
+	}
+
+	ownerOrg, slug, _ := strings.Cut(strings.TrimPrefix(owner, "@"), "/")
+	if !strings.EqualFold(ownerOrg, org) {
+		return "", false
+	}
+	return strings.ToLower(slug), true
+}
+
+// UserLogin returns the login of an @user owner
+func UserLogin(owner string) (string, bool) {
+	if OwnerKind(owner) != "user" {
+		return "", false
+	}
+	return strings.TrimPrefix(owner, "@"), true
+}
+
+// IsWholeRepo reports whether a pattern matches every file in the repository
+func IsWholeRepo(pattern string) bool {
+	switch pattern {
+	case "*", "/", "/*", "**", "/**", "/**/*":
+		return true
+	}
+	return false
+}
+
+// Teams returns the sorted, unique slugs of the org's teams named in the rules
+func Teams(rules []*Rule, org string) []string {
+	seen := make(map[string]bool)
+	var slugs []string
+
+	for _, rule := range rules {
+		for _, owner := range rule.Owners {
+			if slug, ok := TeamSlug(owner, org); ok && !seen[slug] {
+				seen[slug] = true
+				slugs = append(slugs, slug)
+			}
+		}
+	}
+
+	sort.Strings(slugs)
+	return slugs
+}
@@ -0,0 +1,82 @@
+package codeowners
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	content := `# Owners of everything
+*       @acme/platform
+
+/docs/  @acme/docs docs@example.com # trailing comment
+  /api/**   @acme/payments @alice
+/\#hash  @bob
+/unowned/
+`
+	want := []*Rule{
+		{Line: 2, Pattern: "*", Owners: []string{"@acme/platform"}},
+		{Line: 4, Pattern: "/docs/", Owners: []string{"@acme/docs", "docs@example.com"}},
+		{Line: 5, Pattern: "/api/**", Owners: []string{"@acme/payments", "@alice"}},
+		{Line: 6, Pattern: `/\#hash`, Owners: []string{"@bob"}},
+		{Line: 7, Pattern: "/unowned/", Owners: []string{}},
+	}
+
+	got := Parse(content)
+	if len(got) != len(want) {
+		t.Fatalf("Parse() = %d rules, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("rule %d = %+v, want %+v", i, *got[i], *want[i])
+		}
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	for _, content := range []string{"", "\n\n", "# only comments\n  # indented\n"} {
+		if got := Parse(content); len(got) != 0 {
+			t.Errorf("Parse(%q) = %d rules, want none", content, len(got))
+		}
+	}
+}
+
+func TestOwners(t *testing.T) {
+	tests := []struct {
+		owner string
+		kind  string
+		slug  string // team slug in acme, "" if not a team of acme
+		login string // "" if not a user
+	}{
+		{owner: "@acme/payments", kind: "team", slug: "payments"},
+		{owner: "@ACME/Payments", kind: "team", slug: "payments"},
+		{owner: "@other/payments", kind: "team"},
+		{owner: "@alice", kind: "user", login: "alice"},
+		{owner: "alice@example.com", kind: "email"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.owner, func(t *testing.T) {
+			if got := OwnerKind(tt.owner); got != tt.kind {
+				t.Errorf("OwnerKind() = %q, want %q", got, tt.kind)
+			}
+			if slug, ok := TeamSlug(tt.owner, "acme"); slug != tt.slug || ok != (tt.slug != "") {
+				t.Errorf("TeamSlug() = %q, %v, want %q", slug, ok, tt.slug)
+			}
+			if login, ok := UserLogin(tt.owner); login != tt.login || ok != (tt.login != "") {
+				t.Errorf("UserLogin() = %q, %v, want %q", login, ok, tt.login)
+			}
+		})
+	}
+}
+
+func TestTeams(t *testing.T) {
+	rules := Parse(`* @acme/web @acme/platform
+/api/ @Acme/Platform @other/api @alice
+/docs/ @acme/docs`)
+
+	want := []string{"docs", "platform", "web"}
+	if got := Teams(rules, "acme"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Teams() = %q, want %q", got, want)
+	}
+}
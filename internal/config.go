@@ -1,27 +1,184 @@
 package internal
 
 import (
-	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+
+	"github.com/lordzsolt/town/internal/paths"
 )
 
-const appName = "town"
+// Settings are the values that can be set at the top level of the config,
+// in a named profile and in a project config
+type Settings struct {
+	DefaultOrg  string `json:"default_org,omitempty" yaml:"default_org,omitempty" toml:"default_org,omitempty"`
+	DefaultTeam string `json:"default_team,omitempty" yaml:"default_team,omitempty" toml:"default_team,omitempty"`
+
+	// Host is a GitHub Enterprise Server hostname; empty means github.com
+	Host string `json:"host,omitempty" yaml:"host,omitempty" toml:"host,omitempty"`
+
+	// TokenSource restricts where the token is looked up:
+	// auto (default), env, gh, git-credential or keyring
+	TokenSource string `json:"token_source,omitempty" yaml:"token_source,omitempty" toml:"token_source,omitempty"`
+
+	CloneDir      string `json:"clone_dir,omitempty" yaml:"clone_dir,omitempty" toml:"clone_dir,omitempty"`
+	CloneLayout   string `json:"clone_layout,omitempty" yaml:"clone_layout,omitempty" toml:"clone_layout,omitempty"`       // flat (default), org or host
+	CloneProtocol string `json:"clone_protocol,omitempty" yaml:"clone_protocol,omitempty" toml:"clone_protocol,omitempty"` // https (default) or ssh
+
+	// Output is the default output format of `town query`: table, csv or json
+	Output string `json:"output,omitempty" yaml:"output,omitempty" toml:"output,omitempty"`
+
+	// GitHub App authentication, used instead of a personal token when set
+	AppID             int64  `json:"app_id,omitempty" yaml:"app_id,omitempty" toml:"app_id,omitzero"`
+	AppPrivateKey     string `json:"app_private_key,omitempty" yaml:"app_private_key,omitempty" toml:"app_private_key,omitempty"`            // path to the app's PEM private key
+	AppInstallationID int64  `json:"app_installation_id,omitempty" yaml:"app_installation_id,omitempty" toml:"app_installation_id,omitzero"` // discovered from the org if unset
+
+	// OAuthClientID is the OAuth app used by `town auth login --web`
+	OAuthClientID string `json:"oauth_client_id,omitempty" yaml:"oauth_client_id,omitempty" toml:"oauth_client_id,omitempty"`
+}
 
 // Config holds the application configuration
 type Config struct {
-	DefaultOrg  string `json:"default_org"`
-	DefaultTeam string `json:"default_team"`
+	Settings `yaml:",inline"`
+
+	// Profiles are named sets of settings applied on top of the top-level
+	// ones, selected with --profile or CurrentProfile
+	Profiles       map[string]*Settings `json:"profiles,omitempty" yaml:"profiles,omitempty" toml:"profiles,omitempty"`
+	CurrentProfile string               `json:"current_profile,omitempty" yaml:"current_profile,omitempty" toml:"current_profile,omitempty"`
+
+	// UnknownKeys lists keys found in the config file that town doesn't know
+	UnknownKeys []string `json:"-" yaml:"-" toml:"-"`
+
+	// Project holds the settings of the project config (.town.json or
+	// .town.yaml) found in the working directory or its parents, if any.
+	// It is never written back by SaveConfig.
+	Project     *Settings `json:"-" yaml:"-" toml:"-"`
+	ProjectPath string    `json:"-" yaml:"-" toml:"-"`
+	// ProjectIgnoredKeys lists keys of the project config that it may not
+	// set, and that were ignored
+	ProjectIgnoredKeys []string `json:"-" yaml:"-" toml:"-"`
+}
+
+// Resolve returns the effective settings, layered from lowest to highest
+// priority: top-level settings, the named profile, the project config,
+// TOWN_* environment variables. An empty profile name selects $TOWN_PROFILE
+// or CurrentProfile.
+// Command line flags are applied on top of the result by the caller.
+func (c *Config) Resolve(profile string) (*Settings, error) {
+	settings := c.Settings
+
+	explicit := profile != ""
+	if !explicit {
+		profile = os.Getenv(profileEnvVar)
+		explicit = profile != ""
+	}
+	if !explicit {
+		profile = c.CurrentProfile
+	}
+
+	if profile != "" {
+		p, ok := c.Profiles[profile]
+		switch {
+		case ok && p != nil:
+			settings.Merge(p)
+		case explicit:
+			return nil, fmt.Errorf("profile '%s' not found in config", profile)
+		}
+		// A dangling current_profile is reported by Validate, and must not
+		// prevent fixing it with `town config`
+	}
+
+	if c.Project != nil {
+		settings.Merge(c.Project)
+	}
+
+	if err := settings.ApplyEnv(); err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// ActiveProfile returns the name of the profile Resolve applies, "" if none
+func (c *Config) ActiveProfile(profile string) string {
+	if profile == "" {
+		profile = os.Getenv(profileEnvVar)
+	}
+	if profile == "" {
+		profile = c.CurrentProfile
+	}
+	if _, ok := c.Profiles[profile]; !ok {
+		return ""
+	}
+	return profile
+}
+
+// Merge overrides s with the non-empty values of other
+func (s *Settings) Merge(other *Settings) {
+	for _, k := range ConfigKeys {
+		if !k.InProfile {
+			continue
+		}
+		if value := k.value(nil, other); value != "" {
+			k.setValue(nil, s, value)
+		}
+	}
+}
+
+// ApplyEnv overrides s with the TOWN_* environment variables that are set,
+// e.g. TOWN_ORG or TOWN_CLONE_DIR
+func (s *Settings) ApplyEnv() error {
+	for _, k := range ConfigKeys {
+		if !k.InProfile {
+			continue
+		}
+		value, ok := os.LookupEnv(k.EnvVar())
+		if !ok || value == "" {
+			continue
+		}
+		if err := k.Validate(value); err != nil {
+			return fmt.Errorf("%s: %w", k.EnvVar(), err)
+		}
+		k.setValue(nil, s, value)
+	}
+	return nil
+}
+
+// ProfileNames returns the names of all profiles, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // LoadConfig reads the config file following XDG Base Directory Specification.
 // It checks in order:
-//  1. $XDG_CONFIG_HOME/town/config.json
-//  2. ~/.town/config.json (fallback)
+//  1. $XDG_CONFIG_HOME/town/config.{json,yaml,yml,toml} ($XDG_CONFIG_HOME defaults to ~/.config)
+//  2. ~/.town/config.{json,yaml,yml,toml} (legacy fallback)
+//
+// A project config found from the working directory is loaded into
+// Config.Project.
 //
 // Returns an empty Config (not an error) if no config file exists.
 func LoadConfig() (*Config, error) {
+	cfg, err := loadUserConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadProjectConfig(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadUserConfig reads the user's config file
+func loadUserConfig() (*Config, error) {
 	configPath, err := findConfigFile()
 	if err != nil {
 		// No config file found - return empty config
@@ -37,10 +194,17 @@ func LoadConfig() (*Config, error) {
 	}
 
 	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, err
+	if err := unmarshalConfig(configPath, data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", configPath, err)
 	}
 
+	// Report unknown keys instead of silently ignoring them
+	var raw map[string]any
+	if err := unmarshalConfig(configPath, data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", configPath, err)
+	}
+	cfg.UnknownKeys = unknownKeys(raw)
+
 	return &cfg, nil
 }
 
@@ -60,21 +224,27 @@ func findConfigFile() (string, error) {
 
 // getConfigPaths returns the list of config file paths to check, in priority order.
 func getConfigPaths() []string {
-	var paths []string
+	var dirs []string
 
 	// XDG_CONFIG_HOME or default ~/.config
-	configHome := os.Getenv("XDG_CONFIG_HOME")
-	if configHome != "" {
-		paths = append(paths, filepath.Join(configHome, appName, "config.json"))
+	if dir, err := paths.ConfigDir(); err == nil {
+		dirs = append(dirs, dir)
 	}
 
-	// Legacy fallback: ~/.town/config.json
-	home, err := os.UserHomeDir()
-	if err == nil {
-		paths = append(paths, filepath.Join(home, "."+appName, "config.json"))
+	// Legacy fallback: ~/.town, if it couldn't be migrated
+	if dir, err := paths.LegacyDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+
+	// config.json, config.yaml, config.yml or config.toml, detected by extension
+	var candidates []string
+	for _, dir := range dirs {
+		for _, name := range ConfigFileNames() {
+			candidates = append(candidates, filepath.Join(dir, name))
+		}
 	}
 
-	return paths
+	return candidates
 }
 
 // ConfigExists returns true if a config file exists
@@ -83,23 +253,42 @@ func ConfigExists() bool {
 	return err == nil
 }
 
-// SaveConfig writes the config to the preferred config file location.
+// ConfigPath returns the path of the config file: the existing one if found,
+// otherwise where SaveConfig would create it.
+func ConfigPath() (string, error) {
+	if path, err := findConfigFile(); err == nil {
+		return path, nil
+	}
+
+	candidates := getConfigPaths()
+	if len(candidates) == 0 {
+		return "", errors.New("no config paths found")
+	}
+	return candidates[0], nil
+}
+
+// SaveConfig writes the config to the file it was loaded from, or the
+// preferred config file location if there is none yet. Keys town doesn't
+// know are kept.
 // Creates the config directory if it doesn't exist.
 func SaveConfig(cfg *Config) error {
-	paths := getConfigPaths()
-	if len(paths) == 0 {
-		return errors.New("no config paths found")
+	configPath, err := ConfigPath()
+	if err != nil {
+		return err
 	}
 
-	configPath := getConfigPaths()[0]
-
 	// Ensure directory exists
 	configDir := filepath.Dir(configPath)
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return err
 	}
 
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	old, err := os.ReadFile(configPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	data, err := marshalConfig(configPath, old, cfg)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,283 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFormats lists the supported config file formats
+var ConfigFormats = []string{"json", "yaml", "toml"}
+
+// configFileExtensions lists the config file extensions, in the order config
+// files are looked up
+var configFileExtensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// ConfigFileNames returns the names of the config files, in the order they
+// are looked up in a directory
+func ConfigFileNames() []string {
+	var names []string
+	for _, ext := range configFileExtensions {
+		names = append(names, "config"+ext)
+	}
+	return names
+}
+
+// configExtensions maps file extensions to config formats
+var configExtensions = map[string]string{
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".toml": "toml",
+}
+
+// configFormat returns the format of a config file, based on its extension
+func configFormat(path string) string {
+	if format, ok := configExtensions[filepath.Ext(path)]; ok {
+		return format
+	}
+	return "json"
+}
+
+// unmarshalConfig decodes a config file, choosing the format by its extension
+func unmarshalConfig(path string, data []byte, v any) error {
+	switch configFormat(path) {
+	case "yaml":
+		return yaml.Unmarshal(data, v)
+	case "toml":
+		return toml.Unmarshal(data, v)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// marshalConfig encodes cfg in the format of path. old is the current content
+// of the file, nil if there is none: the keys town doesn't know (typos, or
+// keys of a newer version) are kept. YAML files also keep their comments;
+// JSON has none, and the TOML encoder can't keep them.
+func marshalConfig(path string, old []byte, cfg *Config) ([]byte, error) {
+	format := configFormat(path)
+	if format == "yaml" {
+		return marshalYAMLConfig(old, cfg)
+	}
+
+	// Decode cfg back into a map, so unknown keys can be added to it
+	updated := make(map[string]any)
+	encoded, err := encodeConfig(format, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := decodeConfigMap(format, encoded, updated); err != nil {
+		return nil, err
+	}
+	previous := make(map[string]any)
+	if old != nil && decodeConfigMap(format, old, previous) == nil {
+		keepUnknownKeys(previous, updated)
+	}
+	return encodeConfig(format, updated)
+}
+
+// encodeConfig encodes v, a Config or a map, as JSON or TOML
+func encodeConfig(format string, v any) ([]byte, error) {
+	if format == "toml" {
+		var buf bytes.Buffer
+		enc := toml.NewEncoder(&buf)
+		enc.Indent = ""
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// decodeConfigMap decodes a JSON or TOML config into m. JSON numbers are
+// kept as json.Number, so large IDs aren't rounded.
+func decodeConfigMap(format string, data []byte, m map[string]any) error {
+	if format == "toml" {
+		return toml.Unmarshal(data, &m)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(&m)
+}
+
+// keepUnknownKeys copies the unknown keys of the old config to the updated
+// one, at the top level and in the profiles that still exist
+func keepUnknownKeys(old, updated map[string]any) {
+	for key, value := range old {
+		if _, err := LookupConfigKey(key); err != nil && key != "profiles" {
+			updated[key] = value
+		}
+	}
+
+	oldProfiles, _ := old["profiles"].(map[string]any)
+	updatedProfiles, _ := updated["profiles"].(map[string]any)
+	for name, p := range updatedProfiles {
+		oldProfile, _ := oldProfiles[name].(map[string]any)
+		profile, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		for key, value := range oldProfile {
+			if k, err := LookupConfigKey(key); err != nil || !k.InProfile {
+				profile[key] = value
+			}
+		}
+	}
+}
+
+// marshalYAMLConfig updates the old YAML document with cfg's values, so its
+// comments, key order and unknown keys are kept
+func marshalYAMLConfig(old []byte, cfg *Config) ([]byte, error) {
+	var updated yaml.Node
+	if err := updated.Encode(cfg); err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if old == nil || yaml.Unmarshal(old, &doc) != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&updated}}
+	} else {
+		updateYAMLMapping(doc.Content[0], &updated, func(key string) bool {
+			_, err := LookupConfigKey(key)
+			return err == nil || key == "profiles"
+		})
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// updateYAMLMapping sets the entries of updated in the mapping node m and
+// removes the keys town manages (known) that updated no longer has. Values
+// are replaced in place, so the comments around them stay.
+func updateYAMLMapping(m, updated *yaml.Node, known func(key string) bool) {
+	for i := 0; i+1 < len(m.Content); {
+		if key := m.Content[i].Value; known(key) && yamlMappingValue(updated, key) == nil {
+			m.Content = slices.Delete(m.Content, i, i+2)
+			continue
+		}
+		i += 2
+	}
+
+	for i := 0; i+1 < len(updated.Content); i += 2 {
+		key, value := updated.Content[i].Value, updated.Content[i+1]
+		current := yamlMappingValue(m, key)
+		switch {
+		case current == nil:
+			m.Content = append(m.Content, updated.Content[i], value)
+		case key == "profiles" && current.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			updateYAMLProfiles(current, value)
+		default:
+			replaceYAMLValue(current, value)
+		}
+	}
+}
+
+// updateYAMLProfiles updates the profiles mapping node m with updated's
+// profiles, removing the profiles updated doesn't have
+func updateYAMLProfiles(m, updated *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); {
+		if yamlMappingValue(updated, m.Content[i].Value) == nil {
+			m.Content = slices.Delete(m.Content, i, i+2)
+			continue
+		}
+		i += 2
+	}
+
+	for i := 0; i+1 < len(updated.Content); i += 2 {
+		name, profile := updated.Content[i].Value, updated.Content[i+1]
+		current := yamlMappingValue(m, name)
+		switch {
+		case current == nil:
+			m.Content = append(m.Content, updated.Content[i], profile)
+		case current.Kind == yaml.MappingNode && profile.Kind == yaml.MappingNode:
+			updateYAMLMapping(current, profile, func(key string) bool {
+				k, err := LookupConfigKey(key)
+				return err == nil && k.InProfile
+			})
+		default:
+			replaceYAMLValue(current, profile)
+		}
+	}
+}
+
+// replaceYAMLValue replaces the value of node with updated's, keeping the
+// comments of node
+func replaceYAMLValue(node, updated *yaml.Node) {
+	node.Kind, node.Tag, node.Value, node.Style, node.Content = updated.Kind, updated.Tag, updated.Value, updated.Style, updated.Content
+}
+
+// yamlMappingValue returns the value of key in a mapping node, nil if missing
+func yamlMappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// MigrateConfig converts the config file to format, keeping all known values.
+// The old file is renamed to <name>.bak so it no longer takes precedence.
+// Returns the paths of the new and the backed up file.
+func MigrateConfig(cfg *Config, format string) (string, string, error) {
+	if !slices.Contains(ConfigFormats, format) {
+		return "", "", fmt.Errorf("invalid config format '%s': use one of %s", format, strings.Join(ConfigFormats, ", "))
+	}
+
+	oldPath, err := findConfigFile()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", "", errors.New("no config file to migrate")
+		}
+		return "", "", err
+	}
+	if configFormat(oldPath) == format {
+		return "", "", fmt.Errorf("%s is already in %s format", oldPath, format)
+	}
+
+	newPath := strings.TrimSuffix(oldPath, filepath.Ext(oldPath)) + "." + format
+	if _, err := os.Stat(newPath); err == nil {
+		return "", "", fmt.Errorf("%s already exists", newPath)
+	}
+
+	data, err := marshalConfig(newPath, nil, cfg)
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(newPath, data, 0644); err != nil {
+		return "", "", err
+	}
+
+	backupPath := oldPath + ".bak"
+	if err := os.Rename(oldPath, backupPath); err != nil {
+		os.Remove(newPath)
+		return "", "", err
+	}
+	return newPath, backupPath, nil
+}
@@ -0,0 +1,286 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// useConfigDir points the config lookup at a temporary directory, without a
+// legacy ~/.town, and returns town's config directory in it
+func useConfigDir(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", root)
+	t.Setenv("HOME", root)
+	dir := filepath.Join(root, "town")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestMarshalConfig(t *testing.T) {
+	cfg := &Config{
+		Settings: Settings{DefaultOrg: "acme", DefaultTeam: "platform"},
+		Profiles: map[string]*Settings{"work": {Host: "github.example.com", AppID: 123}},
+	}
+
+	tests := []struct {
+		name string
+		path string
+		old  string
+		want string
+	}{
+		{
+			name: "new JSON",
+			path: "config.json",
+			want: `{
+  "default_org": "acme",
+  "default_team": "platform",
+  "profiles": {
+    "work": {
+      "app_id": 123,
+      "host": "github.example.com"
+    }
+  }
+}
+`,
+		},
+		{
+			name: "JSON keeps unknown keys",
+			path: "config.json",
+			old:  `{"default_org": "old", "output": "csv", "future_key": [1, true, null], "profiles": {"work": {"colour": "blue"}, "gone": {"x": 1}}}`,
+			want: `{
+  "default_org": "acme",
+  "default_team": "platform",
+  "future_key": [
+    1,
+    true,
+    null
+  ],
+  "profiles": {
+    "work": {
+      "app_id": 123,
+      "colour": "blue",
+      "host": "github.example.com"
+    }
+  }
+}
+`,
+		},
+		{
+			name: "new YAML",
+			path: "config.yml",
+			want: `default_org: acme
+default_team: platform
+profiles:
+  work:
+    host: github.example.com
+    app_id: 123
+`,
+		},
+		{
+			name: "YAML keeps comments, order and unknown keys",
+			path: "config.yaml",
+			old: `# town config
+typo: 1
+output: csv # removed
+default_org: old # the org
+profiles:
+  # work profile
+  work:
+    host: old.example.com # GHES
+    colour: blue
+  gone:
+    default_org: gone
+`,
+			want: `# town config
+typo: 1
+default_org: acme # the org
+profiles:
+  # work profile
+  work:
+    host: github.example.com # GHES
+    colour: blue
+    app_id: 123
+default_team: platform
+`,
+		},
+		{
+			name: "new TOML",
+			path: "config.toml",
+			want: `default_org = "acme"
+default_team = "platform"
+
+[profiles]
+[profiles.work]
+app_id = 123
+host = "github.example.com"
+`,
+		},
+		{
+			name: "TOML keeps unknown keys",
+			path: "config.toml",
+			old: `# comments are lost
+default_org = "old"
+typo = 1
+
+[profiles.work]
+colour = "blue"
+`,
+			want: `default_org = "acme"
+default_team = "platform"
+typo = 1
+
+[profiles]
+[profiles.work]
+app_id = 123
+colour = "blue"
+host = "github.example.com"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var old []byte
+			if tt.old != "" {
+				old = []byte(tt.old)
+			}
+			got, err := marshalConfig(tt.path, old, cfg)
+			if err != nil {
+				t.Fatalf("marshalConfig(): %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("marshalConfig() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalConfigRoundTrip(t *testing.T) {
+	cfg := &Config{
+		Settings:       Settings{DefaultOrg: "acme", CloneLayout: "org", AppInstallationID: 1 << 53},
+		Profiles:       map[string]*Settings{"work": {Host: "github.example.com"}, "my side": {DefaultOrg: "side"}},
+		CurrentProfile: "work",
+	}
+
+	for _, path := range []string{"config.json", "config.yaml", "config.toml"} {
+		t.Run(path, func(t *testing.T) {
+			data, err := marshalConfig(path, nil, cfg)
+			if err != nil {
+				t.Fatalf("marshalConfig(): %v", err)
+			}
+			var got Config
+			if err := unmarshalConfig(path, data, &got); err != nil {
+				t.Fatalf("unmarshalConfig(): %v\n%s", err, data)
+			}
+			if !reflect.DeepEqual(&got, cfg) {
+				t.Errorf("round trip = %+v, want %+v", got, *cfg)
+			}
+		})
+	}
+}
+
+func TestLoadAndSaveConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "JSON", file: "config.json", content: `{"default_org": "acme", "typo": true}`},
+		{name: "YAML", file: "config.yaml", content: "# my org\ndefault_org: acme\ntypo: true\n"},
+		{name: "YML", file: "config.yml", content: "default_org: acme\ntypo: true\n"},
+		{name: "TOML", file: "config.toml", content: "default_org = \"acme\"\ntypo = true\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := useConfigDir(t)
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := loadUserConfig()
+			if err != nil {
+				t.Fatalf("loadUserConfig(): %v", err)
+			}
+			if cfg.DefaultOrg != "acme" || !reflect.DeepEqual(cfg.UnknownKeys, []string{"typo"}) {
+				t.Fatalf("loadUserConfig() = %+v, want default_org acme and unknown key typo", cfg)
+			}
+
+			cfg.DefaultTeam = "platform"
+			if err := SaveConfig(cfg); err != nil {
+				t.Fatalf("SaveConfig(): %v", err)
+			}
+			saved, err := loadUserConfig()
+			if err != nil {
+				t.Fatalf("loadUserConfig() after saving: %v", err)
+			}
+			if saved.DefaultOrg != "acme" || saved.DefaultTeam != "platform" || !reflect.DeepEqual(saved.UnknownKeys, []string{"typo"}) {
+				t.Errorf("saved config = %+v, want the org, the new team and the unknown key", saved)
+			}
+		})
+	}
+}
+
+func TestConfigFilePriority(t *testing.T) {
+	dir := useConfigDir(t)
+	for _, name := range []string{"config.toml", "config.yml", "config.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := findConfigFile()
+		if want := filepath.Join(dir, name); err != nil || got != want {
+			t.Errorf("findConfigFile() = %s, %v, want %s", got, err, want)
+		}
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	dir := useConfigDir(t)
+	cfg := &Config{
+		Settings: Settings{DefaultOrg: "acme", AppID: 42},
+		Profiles: map[string]*Settings{"work": {DefaultTeam: "platform"}},
+	}
+
+	if _, _, err := MigrateConfig(cfg, "yaml"); err == nil {
+		t.Fatal("MigrateConfig() without a config file succeeded, want an error")
+	}
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := MigrateConfig(cfg, "json"); err == nil {
+		t.Error("MigrateConfig() to the same format succeeded, want an error")
+	}
+	if _, _, err := MigrateConfig(cfg, "ini"); err == nil {
+		t.Error("MigrateConfig() to an unknown format succeeded, want an error")
+	}
+
+	for _, format := range []string{"toml", "yaml", "json"} {
+		newPath, backupPath, err := MigrateConfig(cfg, format)
+		if err != nil {
+			t.Fatalf("MigrateConfig(%s): %v", format, err)
+		}
+		if want := filepath.Join(dir, "config."+format); newPath != want {
+			t.Errorf("MigrateConfig(%s) wrote %s, want %s", format, newPath, want)
+		}
+		if _, err := os.Stat(backupPath); err != nil {
+			t.Errorf("MigrateConfig(%s) backup: %v", format, err)
+		}
+		if err := os.Remove(backupPath); err != nil {
+			t.Fatal(err)
+		}
+
+		migrated, err := loadUserConfig()
+		if err != nil {
+			t.Fatalf("loadUserConfig() after migrating to %s: %v", format, err)
+		}
+		if !reflect.DeepEqual(migrated, cfg) {
+			t.Errorf("config migrated to %s = %+v, want %+v", format, migrated, cfg)
+		}
+	}
+}
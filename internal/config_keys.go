@@ -0,0 +1,248 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	gh "github.com/lordzsolt/town/internal/github"
+)
+
+// profileEnvVar selects the profile, like --profile
+const profileEnvVar = "TOWN_PROFILE"
+
+// ConfigKey describes a config setting that can be viewed and edited with `town config`
+type ConfigKey struct {
+	Name        string
+	Description string
+	// InProfile is true for settings that can also be set in a profile
+	// or overridden with an environment variable
+	InProfile bool
+	// InProject is true for settings that a project config may pin. It is
+	// limited to settings that can't redirect the user's credentials.
+	InProject bool
+	// Values lists the allowed values, if restricted
+	Values []string
+	// Env is the environment variable overriding the key, if it isn't
+	// TOWN_ followed by the upper-cased name
+	Env string
+
+	str func(c *Config, s *Settings) *string
+	num func(s *Settings) *int64
+}
+
+// ConfigKeys lists every supported config key
+var ConfigKeys = []*ConfigKey{
+	{Name: "default_org", Description: "Organization used when --org is omitted", InProfile: true, InProject: true, Env: "TOWN_ORG",
+		str: func(c *Config, s *Settings) *string { return &s.DefaultOrg }},
+	{Name: "default_team", Description: "Team used when --team is omitted", InProfile: true, InProject: true, Env: "TOWN_TEAM",
+		str: func(c *Config, s *Settings) *string { return &s.DefaultTeam }},
+	{Name: "host", Description: "GitHub Enterprise Server hostname (default github.com)", InProfile: true,
+		str: func(c *Config, s *Settings) *string { return &s.Host }},
+	{Name: "token_source", Description: "Where to look up the token", InProfile: true,
+		Values: gh.TokenSources,
+		str:    func(c *Config, s *Settings) *string { return &s.TokenSource }},
+	{Name: "clone_dir", Description: "Directory to clone repositories into", InProfile: true, InProject: true,
+		str: func(c *Config, s *Settings) *string { return &s.CloneDir }},
+	{Name: "clone_layout", Description: "Directory layout of cloned repositories", InProfile: true, InProject: true,
+		Values: CloneLayouts,
+		str:    func(c *Config, s *Settings) *string { return &s.CloneLayout }},
+	{Name: "clone_protocol", Description: "Protocol used to clone repositories", InProfile: true, InProject: true,
+		Values: CloneProtocols,
+		str:    func(c *Config, s *Settings) *string { return &s.CloneProtocol }},
+	{Name: "output", Description: "Default output format of 'town query'", InProfile: true,
+		Values: []string{"table", "csv", "json"},
+		str:    func(c *Config, s *Settings) *string { return &s.Output }},
+	{Name: "app_id", Description: "GitHub App ID to authenticate as", InProfile: true,
+		num: func(s *Settings) *int64 { return &s.AppID }},
+	{Name: "app_private_key", Description: "Path to the GitHub App's private key", InProfile: true,
+		str: func(c *Config, s *Settings) *string { return &s.AppPrivateKey }},
+	{Name: "app_installation_id", Description: "GitHub App installation ID (discovered if unset)", InProfile: true,
+		num: func(s *Settings) *int64 { return &s.AppInstallationID }},
+	{Name: "oauth_client_id", Description: "OAuth app used by 'town auth login --web'", InProfile: true,
+		str: func(c *Config, s *Settings) *string { return &s.OAuthClientID }},
+	{Name: "current_profile", Description: "Profile used when --profile is omitted", Env: profileEnvVar,
+		str: func(c *Config, s *Settings) *string { return &c.CurrentProfile }},
+}
+
+// EnvVar returns the environment variable overriding the key
+func (k *ConfigKey) EnvVar() string {
+	if k.Env != "" {
+		return k.Env
+	}
+	return "TOWN_" + strings.ToUpper(k.Name)
+}
+
+// value returns the key's value in c or s as a string, "" if unset.
+// c is only needed for keys that aren't InProfile.
+func (k *ConfigKey) value(c *Config, s *Settings) string {
+	if k.num != nil {
+		if n := *k.num(s); n != 0 {
+			return strconv.FormatInt(n, 10)
+		}
+		return ""
+	}
+	return *k.str(c, s)
+}
+
+// setValue stores an already validated value in c or s
+func (k *ConfigKey) setValue(c *Config, s *Settings, value string) {
+	if k.num != nil {
+		n, _ := strconv.ParseInt(value, 10, 64)
+		*k.num(s) = n
+		return
+	}
+	*k.str(c, s) = value
+}
+
+// LookupConfigKey returns the config key with the given name
+func LookupConfigKey(name string) (*ConfigKey, error) {
+	for _, k := range ConfigKeys {
+		if k.Name == name {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown config key '%s'", name)
+}
+
+// settings returns the settings the key is read from and written to:
+// the named profile's, or the top-level ones if profile is empty
+func (k *ConfigKey) settings(c *Config, profile string, create bool) (*Settings, error) {
+	if profile == "" {
+		return &c.Settings, nil
+	}
+	if !k.InProfile {
+		return nil, fmt.Errorf("'%s' can't be set in a profile", k.Name)
+	}
+
+	p, ok := c.Profiles[profile]
+	if !ok || p == nil {
+		if !create {
+			return nil, fmt.Errorf("profile '%s' not found in config", profile)
+		}
+		if c.Profiles == nil {
+			c.Profiles = make(map[string]*Settings)
+		}
+		p = &Settings{}
+		c.Profiles[profile] = p
+	}
+	return p, nil
+}
+
+// Get returns the key's value at the top level or in the named profile.
+// Returns "" if the key isn't set.
+func (k *ConfigKey) Get(c *Config, profile string) (string, error) {
+	s, err := k.settings(c, profile, false)
+	if err != nil {
+		return "", err
+	}
+	return k.value(c, s), nil
+}
+
+// Set validates and stores the key's value at the top level or in the named
+// profile, creating the profile if needed
+func (k *ConfigKey) Set(c *Config, profile, value string) error {
+	if err := k.Validate(value); err != nil {
+		return err
+	}
+
+	s, err := k.settings(c, profile, true)
+	if err != nil {
+		return err
+	}
+	k.setValue(c, s, value)
+	return nil
+}
+
+// Unset removes the key's value at the top level or in the named profile
+func (k *ConfigKey) Unset(c *Config, profile string) error {
+	s, err := k.settings(c, profile, false)
+	if err != nil {
+		return err
+	}
+
+	if k.num != nil {
+		*k.num(s) = 0
+		return nil
+	}
+	*k.str(c, s) = ""
+	return nil
+}
+
+// Validate checks the value's type and, for restricted keys, that it is allowed
+func (k *ConfigKey) Validate(value string) error {
+	if k.num != nil {
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("'%s' must be a number", k.Name)
+		}
+	}
+	if len(k.Values) > 0 && !slices.Contains(k.Values, value) {
+		return fmt.Errorf("invalid value '%s' for '%s': use one of %s", value, k.Name, strings.Join(k.Values, ", "))
+	}
+	return nil
+}
+
+// Validate checks the config's values, returning a problem description per invalid value
+func (c *Config) Validate() []string {
+	var problems []string
+	for _, key := range c.UnknownKeys {
+		problems = append(problems, fmt.Sprintf("unknown key '%s'", key))
+	}
+
+	for _, name := range append([]string{""}, c.ProfileNames()...) {
+		for _, k := range ConfigKeys {
+			if name != "" && !k.InProfile {
+				continue
+			}
+			value, err := k.Get(c, name)
+			if err != nil || value == "" {
+				continue
+			}
+			if err := k.Validate(value); err != nil {
+				if name != "" {
+					problems = append(problems, fmt.Sprintf("profile '%s': %v", name, err))
+				} else {
+					problems = append(problems, err.Error())
+				}
+			}
+		}
+	}
+
+	if c.CurrentProfile != "" {
+		if _, ok := c.Profiles[c.CurrentProfile]; !ok {
+			problems = append(problems, fmt.Sprintf("current_profile '%s' not found in profiles", c.CurrentProfile))
+		}
+	}
+
+	return problems
+}
+
+// unknownKeys returns the keys in raw that aren't config keys, e.g. typos.
+// Profile keys are reported as profiles.<name>.<key>.
+func unknownKeys(raw map[string]any) []string {
+	var unknown []string
+
+	for key, value := range raw {
+		if key == "profiles" {
+			profiles, _ := value.(map[string]any)
+			for name, p := range profiles {
+				fields, _ := p.(map[string]any)
+				for field := range fields {
+					if k, err := LookupConfigKey(field); err != nil || !k.InProfile {
+						unknown = append(unknown, "profiles."+name+"."+field)
+					}
+				}
+			}
+			continue
+		}
+
+		if _, err := LookupConfigKey(key); err != nil {
+			unknown = append(unknown, key)
+		}
+	}
+
+	sort.Strings(unknown)
+	return unknown
+}
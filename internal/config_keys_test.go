@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  map[string]any
+		want []string
+	}{
+		{
+			name: "known keys",
+			raw:  map[string]any{"default_org": "acme", "current_profile": "work"},
+		},
+		{
+			name: "typos",
+			raw:  map[string]any{"default_orgs": "acme", "clonedir": "~/src", "default_team": "eng"},
+			want: []string{"clonedir", "default_orgs"},
+		},
+		{
+			name: "profile keys",
+			raw: map[string]any{"profiles": map[string]any{
+				"work": map[string]any{"default_org": "acme", "current_profile": "oss", "hots": "x"},
+			}},
+			want: []string{"profiles.work.current_profile", "profiles.work.hots"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unknownKeys(tt.raw); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unknownKeys() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigKeyValidate(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{key: "default_org", value: "anything"},
+		{key: "clone_layout", value: "org"},
+		{key: "clone_layout", value: "nested", wantErr: true},
+		{key: "output", value: "json"},
+		{key: "output", value: "yaml", wantErr: true},
+		{key: "app_id", value: "12345"},
+		{key: "app_id", value: "twelve", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			k, err := LookupConfigKey(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := k.Validate(tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) = %v, want error: %v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLookupConfigKey(t *testing.T) {
+	if _, err := LookupConfigKey("default_orgs"); err == nil {
+		t.Error("LookupConfigKey(\"default_orgs\") succeeded, want an error")
+	}
+}
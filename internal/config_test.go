@@ -0,0 +1,122 @@
+package internal
+
+import "testing"
+
+// clearConfigEnv unsets the environment variables Resolve reads, for the
+// duration of the test
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+	for _, k := range ConfigKeys {
+		t.Setenv(k.EnvVar(), "")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	cfg := &Config{
+		Settings: Settings{DefaultOrg: "top", DefaultTeam: "top-team", CloneLayout: "flat"},
+		Profiles: map[string]*Settings{
+			"work": {DefaultOrg: "work", Host: "github.example.com"},
+			"oss":  {DefaultOrg: "oss"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		current string
+		project *Settings
+		profile string
+		env     map[string]string
+		want    Settings
+		wantErr bool
+	}{
+		{
+			name: "top level only",
+			want: Settings{DefaultOrg: "top", DefaultTeam: "top-team", CloneLayout: "flat"},
+		},
+		{
+			name:    "profile over top level",
+			profile: "work",
+			want:    Settings{DefaultOrg: "work", DefaultTeam: "top-team", CloneLayout: "flat", Host: "github.example.com"},
+		},
+		{
+			name:    "current profile",
+			current: "oss",
+			want:    Settings{DefaultOrg: "oss", DefaultTeam: "top-team", CloneLayout: "flat"},
+		},
+		{
+			name:    "flag over current profile",
+			current: "oss",
+			profile: "work",
+			want:    Settings{DefaultOrg: "work", DefaultTeam: "top-team", CloneLayout: "flat", Host: "github.example.com"},
+		},
+		{
+			name:    "environment selects the profile",
+			current: "oss",
+			env:     map[string]string{"TOWN_PROFILE": "work"},
+			want:    Settings{DefaultOrg: "work", DefaultTeam: "top-team", CloneLayout: "flat", Host: "github.example.com"},
+		},
+		{
+			name:    "project over profile",
+			profile: "work",
+			project: &Settings{DefaultOrg: "project", CloneLayout: "org"},
+			want:    Settings{DefaultOrg: "project", DefaultTeam: "top-team", CloneLayout: "org", Host: "github.example.com"},
+		},
+		{
+			name:    "environment over project",
+			project: &Settings{DefaultOrg: "project", DefaultTeam: "project-team"},
+			env:     map[string]string{"TOWN_ORG": "env", "TOWN_CLONE_LAYOUT": "host"},
+			want:    Settings{DefaultOrg: "env", DefaultTeam: "project-team", CloneLayout: "host"},
+		},
+		{
+			name:    "dangling current profile is ignored",
+			current: "gone",
+			want:    Settings{DefaultOrg: "top", DefaultTeam: "top-team", CloneLayout: "flat"},
+		},
+		{
+			name:    "missing profile",
+			profile: "gone",
+			wantErr: true,
+		},
+		{
+			name:    "invalid environment value",
+			env:     map[string]string{"TOWN_CLONE_LAYOUT": "nested"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			c := *cfg
+			c.CurrentProfile = tt.current
+			c.Project = tt.project
+
+			got, err := c.Resolve(tt.profile)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Resolve(%q) = %+v, want an error", tt.profile, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q): %v", tt.profile, err)
+			}
+			if *got != tt.want {
+				t.Errorf("Resolve(%q) = %+v, want %+v", tt.profile, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	s := Settings{DefaultOrg: "org", CloneDir: "~/src", AppID: 1}
+	s.Merge(&Settings{DefaultOrg: "other", AppID: 2, AppInstallationID: 3})
+
+	want := Settings{DefaultOrg: "other", CloneDir: "~/src", AppID: 2, AppInstallationID: 3}
+	if s != want {
+		t.Errorf("Merge() = %+v, want %+v", s, want)
+	}
+}
@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lordzsolt/town/internal/cache"
+	"github.com/lordzsolt/town/internal/codeowners"
+)
+
+// OwnershipChange lists the teams a repository gained or lost in CODEOWNERS
+type OwnershipChange struct {
+	Repo   string
+	Gained []string
+	Lost   []string
+}
+
+// FootprintChange is the change in the number of repositories a team owns
+type FootprintChange struct {
+	Team   string
+	Before int
+	After  int
+}
+
+// SnapshotDiff describes how ownership changed between two snapshots
+type SnapshotDiff struct {
+	From *cache.Snapshot
+	To   *cache.Snapshot
+
+	OwnershipChanges []*OwnershipChange
+	// NewWithoutCodeowners are repositories created without a CODEOWNERS file
+	NewWithoutCodeowners []string
+	// LostCodeowners are existing repositories whose CODEOWNERS file was removed
+	LostCodeowners   []string
+	FootprintChanges []*FootprintChange
+}
+
+// DiffSnapshots compares the ownership in two snapshots of the same org.
+// If team is set, only changes involving that team are reported.
+// Archived repositories are ignored, like in the repos command.
+func DiffSnapshots(from, to *cache.Snapshot, team string) *SnapshotDiff {
+	diff := &SnapshotDiff{From: from, To: to}
+
+	before := ownershipByRepo(from)
+	after := ownershipByRepo(to)
+
+	// Ownership changes of repositories present in either snapshot
+	for _, repo := range sortedKeys(before, after) {
+		gained := difference(after[repo], before[repo])
+		lost := difference(before[repo], after[repo])
+		if team != "" {
+			gained = filterTeam(gained, team)
+			lost = filterTeam(lost, team)
+		}
+		if len(gained) > 0 || len(lost) > 0 {
+			diff.OwnershipChanges = append(diff.OwnershipChanges, &OwnershipChange{Repo: repo, Gained: gained, Lost: lost})
+		}
+	}
+
+	// Repositories that are new, or lost their CODEOWNERS file
+	if team == "" {
+		hadCodeowners := make(map[string]bool)
+		for _, r := range from.Repos {
+			hadCodeowners[r.GetName()] = from.CodeownersContent(r.GetName()) != ""
+		}
+		for _, r := range to.Repos {
+			name := r.GetName()
+			if r.GetArchived() || to.CodeownersContent(name) != "" {
+				continue
+			}
+			had, existed := hadCodeowners[name]
+			switch {
+			case !existed:
+				diff.NewWithoutCodeowners = append(diff.NewWithoutCodeowners, name)
+			case had:
+				diff.LostCodeowners = append(diff.LostCodeowners, name)
+			}
+		}
+		sort.Strings(diff.NewWithoutCodeowners)
+		sort.Strings(diff.LostCodeowners)
+	}
+
+	// Number of repositories owned per team
+	countBefore := countByTeam(before)
+	countAfter := countByTeam(after)
+	for _, slug := range sortedKeys(countBefore, countAfter) {
+		if team != "" && !strings.EqualFold(slug, team) {
+			continue
+		}
+		if countBefore[slug] != countAfter[slug] {
+			diff.FootprintChanges = append(diff.FootprintChanges, &FootprintChange{
+				Team:   slug,
+				Before: countBefore[slug],
+				After:  countAfter[slug],
+			})
+		}
+	}
+
+	return diff
+}
+
+// ownershipByRepo maps each non-archived repository to the teams in its CODEOWNERS
+func ownershipByRepo(snap *cache.Snapshot) map[string][]string {
+	owners := make(map[string][]string)
+	for _, r := range snap.Repos {
+		if r.GetArchived() {
+			continue
+		}
+		rules := codeowners.Parse(snap.CodeownersContent(r.GetName()))
+		owners[r.GetName()] = codeowners.Teams(rules, snap.Org)
+	}
+	return owners
+}
+
+func countByTeam(owners map[string][]string) map[string]int {
+	counts := make(map[string]int)
+	for _, teams := range owners {
+		for _, t := range teams {
+			counts[t]++
+		}
+	}
+	return counts
+}
+
+// difference returns the elements of a that are not in b
+func difference(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, s := range b {
+		inB[s] = true
+	}
+
+	var result []string
+	for _, s := range a {
+		if !inB[s] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// filterTeam returns the team if it's in teams, compared case-insensitively
+func filterTeam(teams []string, team string) []string {
+	for _, t := range teams {
+		if strings.EqualFold(t, team) {
+			return []string{t}
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the union of the keys of both maps, sorted
+func sortedKeys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range []map[string]V{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
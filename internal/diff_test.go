@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/lordzsolt/town/internal/cache"
+
+	"github.com/google/go-github/v58/github"
+)
+
+// testSnapshot builds a snapshot of acme with the given CODEOWNERS content
+// by repository, "" for repositories without CODEOWNERS
+func testSnapshot(codeowners map[string]string, archived ...string) *cache.Snapshot {
+	snap := &cache.Snapshot{Org: "acme", Codeowners: make(map[string]*cache.CodeownersFile)}
+	for _, repo := range sortedKeys(codeowners, nil) {
+		isArchived := slices.Contains(archived, repo)
+		snap.Repos = append(snap.Repos, &github.Repository{Name: github.String(repo), Archived: github.Bool(isArchived)})
+		if content := codeowners[repo]; content != "" {
+			snap.Codeowners[repo] = &cache.CodeownersFile{Path: "CODEOWNERS", Content: content}
+		}
+	}
+	return snap
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	from := testSnapshot(map[string]string{
+		"api":     "* @acme/payments\n/docs/ @acme/docs",
+		"web":     "* @acme/web",
+		"infra":   "* @acme/platform",
+		"old":     "* @acme/payments",
+		"dropped": "* @acme/web",
+		"bare":    "",
+	})
+	to := testSnapshot(map[string]string{
+		"api":     "* @ACME/Payments @acme/platform",
+		"web":     "* @acme/web",
+		"infra":   "* @acme/platform",
+		"old":     "* @acme/payments",
+		"dropped": "",
+		"bare":    "",
+		"new":     "",
+		"owned":   "* @acme/payments",
+	}, "old")
+
+	tests := []struct {
+		name string
+		team string
+		want *SnapshotDiff
+	}{
+		{
+			name: "all teams",
+			want: &SnapshotDiff{
+				OwnershipChanges: []*OwnershipChange{
+					{Repo: "api", Gained: []string{"platform"}, Lost: []string{"docs"}},
+					{Repo: "dropped", Lost: []string{"web"}},
+					{Repo: "old", Lost: []string{"payments"}},
+					{Repo: "owned", Gained: []string{"payments"}},
+				},
+				NewWithoutCodeowners: []string{"new"},
+				LostCodeowners:       []string{"dropped"},
+				FootprintChanges: []*FootprintChange{
+					{Team: "docs", Before: 1, After: 0},
+					{Team: "platform", Before: 1, After: 2},
+					{Team: "web", Before: 2, After: 1},
+				},
+			},
+		},
+		{
+			name: "team matched case-insensitively",
+			team: "Platform",
+			want: &SnapshotDiff{
+				OwnershipChanges: []*OwnershipChange{
+					{Repo: "api", Gained: []string{"platform"}},
+				},
+				FootprintChanges: []*FootprintChange{
+					{Team: "platform", Before: 1, After: 2},
+				},
+			},
+		},
+		{
+			name: "team without changes in footprint",
+			team: "payments",
+			want: &SnapshotDiff{
+				OwnershipChanges: []*OwnershipChange{
+					{Repo: "old", Lost: []string{"payments"}},
+					{Repo: "owned", Gained: []string{"payments"}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffSnapshots(from, to, tt.team)
+			tt.want.From, tt.want.To = from, to
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffSnapshots(%q):\ngot  %s\nwant %s", tt.team, describeDiff(got), describeDiff(tt.want))
+			}
+		})
+	}
+}
+
+// describeDiff formats a diff's changes for test failures
+func describeDiff(d *SnapshotDiff) string {
+	s := "ownership:"
+	for _, c := range d.OwnershipChanges {
+		s += fmt.Sprintf(" %s+%v-%v", c.Repo, c.Gained, c.Lost)
+	}
+	s += fmt.Sprintf(" new:%v lost:%v footprint:", d.NewWithoutCodeowners, d.LostCodeowners)
+	for _, c := range d.FootprintChanges {
+		s += fmt.Sprintf(" %s:%d->%d", c.Team, c.Before, c.After)
+	}
+	return s
+}
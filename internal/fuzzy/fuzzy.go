@@ -0,0 +1,126 @@
+// Package fuzzy ranks strings by how well they match a search query.
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+)
+
+// Scores of the match kinds, best first. Subsequence matches score below
+// scoreSubstring, lowered further by the gaps between matched characters.
+const (
+	scoreExact          = 1000
+	scorePrefix         = 800
+	scoreWordPrefix     = 600
+	scoreSubstring      = 400
+	scoreSubsequenceMax = 200
+)
+
+// Score rates how well query matches s, case-insensitively: an exact match
+// scores highest, followed by a prefix, the start of a word (after -, _, / or
+// a space), a substring and finally the query's characters appearing in order
+// with few gaps.
+// Returns 0 if s doesn't match. An empty query matches everything with score 1.
+func Score(query, s string) int {
+	query = strings.ToLower(strings.TrimSpace(query))
+	s = strings.ToLower(s)
+
+	switch {
+	case query == "":
+		return 1
+	case s == query:
+		return scoreExact
+	case strings.HasPrefix(s, query):
+		return scorePrefix - len(s) // Prefer shorter names
+	}
+
+	if idx := strings.Index(s, query); idx >= 0 {
+		for i := idx; i >= 0; i = nextIndex(s, query, i) {
+			if isWordStart(s, i) {
+				return scoreWordPrefix - len(s)
+			}
+		}
+		return scoreSubstring - len(s)
+	}
+
+	return subsequenceScore(query, s)
+}
+
+func nextIndex(s, query string, after int) int {
+	idx := strings.Index(s[after+1:], query)
+	if idx < 0 {
+		return -1
+	}
+	return after + 1 + idx
+}
+
+func isWordStart(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	switch s[i-1] {
+	case '-', '_', '/', ' ', '.':
+		return true
+	}
+	return false
+}
+
+// subsequenceScore scores query's characters appearing in order in s
+func subsequenceScore(query, s string) int {
+	gaps, pos := 0, 0
+	for _, r := range query {
+		idx := strings.IndexRune(s[pos:], r)
+		if idx < 0 {
+			return 0
+		}
+		gaps += idx
+		pos += idx + len(string(r))
+	}
+
+	// Too scattered to be a meaningful match
+	return max(scoreSubsequenceMax-gaps*5-len(s), 0)
+}
+
+// RankFunc returns the items with a field matching query, best match first.
+// An item's score is the best score of its fields; fields listed first win
+// ties, so more important fields should come first. Items with equal scores
+// keep their order.
+func RankFunc[T any](query string, items []T, fields func(T) []string) []T {
+	type scored struct {
+		item  T
+		score int
+	}
+
+	var matches []scored
+	for _, item := range items {
+		best := 0
+		for i, field := range fields(item) {
+			score := Score(query, field)
+			if score == 0 {
+				continue
+			}
+			// Later fields count slightly less
+			if score = max(score-i, 1); score > best {
+				best = score
+			}
+		}
+		if best > 0 {
+			matches = append(matches, scored{item, best})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	ranked := make([]T, len(matches))
+	for i, m := range matches {
+		ranked[i] = m.item
+	}
+	return ranked
+}
+
+// Rank returns the strings matching query, best match first
+func Rank(query string, items []string) []string {
+	return RankFunc(query, items, func(s string) []string { return []string{s} })
+}
@@ -0,0 +1,83 @@
+package fuzzy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		query string
+		s     string
+		want  int
+	}{
+		{query: "", s: "anything", want: 1},
+		{query: "payments", s: "Payments", want: scoreExact},
+		{query: " pay ", s: "payments", want: scorePrefix - 8},
+		{query: "pay", s: "team-payments", want: scoreWordPrefix - 13},
+		{query: "ment", s: "payments", want: scoreSubstring - 8},
+		{query: "pmt", s: "payments", want: scoreSubsequenceMax - 4*5 - 8},
+		{query: "xyz", s: "payments", want: 0},
+		{query: "tsp", s: "payments", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query+"/"+tt.s, func(t *testing.T) {
+			if got := Score(tt.query, tt.s); got != tt.want {
+				t.Errorf("Score(%q, %q) = %d, want %d", tt.query, tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreOrder(t *testing.T) {
+	// Each name matches "api" better than the next one
+	names := []string{"api", "api-gateway", "payments-api", "rapid", "a-platform-io"}
+	for i := 1; i < len(names); i++ {
+		better, worse := Score("api", names[i-1]), Score("api", names[i])
+		if better <= worse {
+			t.Errorf("Score(\"api\", %q) = %d, want more than Score(\"api\", %q) = %d", names[i-1], better, names[i], worse)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	items := []string{"team-payments", "platform", "payments-core", "design", "payments"}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "pay", want: []string{"payments", "payments-core", "team-payments"}},
+		{query: "plat", want: []string{"platform"}},
+		{query: "nothing", want: nil},
+		{query: "", want: items},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := Rank(tt.query, items)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Rank(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankFunc(t *testing.T) {
+	type team struct{ slug, description string }
+	items := []team{
+		{"billing", "Handles payments"},
+		{"payments", ""},
+		{"search", "Finds things"},
+	}
+
+	got := RankFunc("pay", items, func(t team) []string { return []string{t.slug, t.description} })
+	want := []team{items[1], items[0]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RankFunc(\"pay\") = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v58/github"
+)
+
+// AppCredentials identify a GitHub App to authenticate as
+type AppCredentials struct {
+	AppID          int64
+	PrivateKeyPath string
+	// InstallationID is discovered from the org if zero
+	InstallationID int64
+}
+
+// newAppClient returns a client authenticated as the app's installation on org.
+// Installation tokens are valid for an hour and are refreshed automatically.
+func newAppClient(creds *AppCredentials, host, org string) (*github.Client, error) {
+	if creds.PrivateKeyPath == "" {
+		return nil, errors.New("app_private_key must point to the GitHub App's private key file")
+	}
+
+	pemData, err := os.ReadFile(creds.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading GitHub App private key: %w", err)
+	}
+	key, err := parsePrivateKey(pemData)
+	if err != nil {
+		return nil, fmt.Errorf("parsing GitHub App private key: %w", err)
+	}
+
+	appClient, err := withHost(github.NewClient(&http.Client{
+		Transport: &appTransport{appID: creds.AppID, key: key},
+	}), host)
+	if err != nil {
+		return nil, err
+	}
+
+	installationID := creds.InstallationID
+	if installationID == 0 {
+		if org == "" {
+			return nil, errors.New("organization is required to find the GitHub App installation")
+		}
+		installation, _, err := appClient.Apps.FindOrganizationInstallation(context.Background(), org)
+		if err != nil {
+			return nil, fmt.Errorf("finding GitHub App installation on '%s': %w", org, err)
+		}
+		installationID = installation.GetID()
+	}
+
+	return withHost(github.NewClient(&http.Client{
+		Transport: &installationTransport{apps: appClient.Apps, installationID: installationID},
+	}), host)
+}
+
+func parsePrivateKey(pemData []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// appTransport authenticates requests as the GitHub App itself using a JWT
+type appTransport struct {
+	appID int64
+	key   *rsa.PrivateKey
+}
+
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.jwt(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// jwt creates a signed RS256 JWT, backdated to allow for clock drift
+func (t *appTransport) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(t.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + enc.EncodeToString(signature), nil
+}
+
+// installationTransport authenticates requests with an installation token,
+// creating a new one shortly before the current one expires
+type installationTransport struct {
+	apps           *github.AppsService
+	installationID int64
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func (t *installationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.currentToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (t *installationTransport) currentToken(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && time.Until(t.expiresAt) > time.Minute {
+		return t.token, nil
+	}
+
+	installationToken, _, err := t.apps.CreateInstallationToken(ctx, t.installationID, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating GitHub App installation token: %w", err)
+	}
+
+	t.token = installationToken.GetToken()
+	t.expiresAt = installationToken.GetExpiresAt().Time
+	return t.token, nil
+}
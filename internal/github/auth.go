@@ -0,0 +1,113 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v58/github"
+)
+
+// TokenInfo describes what a token authenticates as
+type TokenInfo struct {
+	Login string
+	// Type is derived from the token prefix, e.g. "fine-grained personal access token"
+	Type string
+	// Scopes are only reported for classic tokens
+	Scopes []string
+	// Expiration is empty if the token doesn't expire
+	Expiration string
+	Rate       github.Rate
+	// EnterpriseVersion is the GitHub Enterprise Server version, empty on github.com
+	EnterpriseVersion string
+}
+
+// ExpiresWithin reports whether the token expires within d
+func (i *TokenInfo) ExpiresWithin(d time.Duration) bool {
+	if i.Expiration == "" {
+		return false
+	}
+	// e.g. "2026-11-01 12:00:00 UTC"
+	expiration, err := time.Parse("2006-01-02 15:04:05 MST", i.Expiration)
+	if err != nil {
+		return false
+	}
+	return time.Until(expiration) < d
+}
+
+// ValidateToken checks the client's token by fetching the authenticated user
+func ValidateToken(ctx context.Context, client *github.Client) (*TokenInfo, error) {
+	user, resp, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	info := &TokenInfo{
+		Login:      user.GetLogin(),
+		Expiration: resp.Header.Get("GitHub-Authentication-Token-Expiration"),
+		Rate:       resp.Rate,
+
+		EnterpriseVersion: resp.Header.Get("X-GitHub-Enterprise-Version"),
+	}
+
+	if scopes := resp.Header.Get("X-OAuth-Scopes"); scopes != "" {
+		for _, s := range strings.Split(scopes, ",") {
+			info.Scopes = append(info.Scopes, strings.TrimSpace(s))
+		}
+	}
+
+	return info, nil
+}
+
+// TokenType describes a token based on its prefix
+func TokenType(token string) string {
+	switch {
+	case strings.HasPrefix(token, "github_pat_"):
+		return "fine-grained personal access token"
+	case strings.HasPrefix(token, "ghp_"):
+		return "classic personal access token"
+	case strings.HasPrefix(token, "gho_"):
+		return "OAuth token"
+	case strings.HasPrefix(token, "ghs_"):
+		return "GitHub App installation token"
+	case strings.HasPrefix(token, "ghu_"):
+		return "GitHub App user token"
+	}
+	return "unknown token type"
+}
+
+// PermissionCheck is the result of probing a permission town needs
+type PermissionCheck struct {
+	Name string
+	Err  error
+}
+
+// CheckPermissions probes the permissions town needs on an org:
+// Members (read) to list teams and Contents (read) to read CODEOWNERS.
+// This works for fine-grained tokens, which don't report their permissions.
+func CheckPermissions(ctx context.Context, client *github.Client, org string) []*PermissionCheck {
+	members := &PermissionCheck{Name: "Organization members (read)"}
+	_, _, members.Err = client.Teams.ListTeams(ctx, org, &github.ListOptions{PerPage: 1})
+
+	contents := &PermissionCheck{Name: "Repository contents (read)"}
+	repos, _, err := client.Repositories.ListByOrg(ctx, org, &github.RepositoryListByOrgOptions{
+		Type:        "all",
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	switch {
+	case err != nil:
+		contents.Err = err
+	case len(repos) == 0:
+		contents.Err = errors.New("no repositories visible to this token")
+	default:
+		_, _, resp, err := client.Repositories.GetContents(ctx, org, repos[0].GetName(), "", nil)
+		// An empty repository has no contents, but the request was still allowed
+		if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+			contents.Err = err
+		}
+	}
+
+	return []*PermissionCheck{members, contents}
+}
@@ -2,11 +2,44 @@ package github
 
 import "github.com/google/go-github/v58/github"
 
-func NewClient() (*github.Client, error) {
-	token, err := getToken()
+// ClientOptions configures how NewClient authenticates
+type ClientOptions struct {
+	// Host is github.com (the default) or a GitHub Enterprise Server hostname.
+	Host string
+
+	// Token is an explicit token, e.g. from the --token flag.
+	// If empty, the token is looked up as described in LookupToken.
+	Token string
+	// TokenSource restricts the lookup to a single source, see TokenSources.
+	TokenSource string
+
+	// Org selects the keyring token stored for the org, if any.
+	Org string
+
+	// App, if set, authenticates as a GitHub App installation on Org
+	// instead of using a token.
+	App *AppCredentials
+}
+
+func NewClient(opts ClientOptions) (*github.Client, error) {
+	if opts.App != nil && opts.Token == "" {
+		return newAppClient(opts.App, opts.Host, opts.Org)
+	}
+
+	token, err := getToken(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	return github.NewClient(nil).WithAuthToken(token), nil
+	return withHost(github.NewClient(nil).WithAuthToken(token), opts.Host)
+}
+
+// withHost points the client at a GitHub Enterprise Server's API
+func withHost(client *github.Client, host string) (*github.Client, error) {
+	if !IsEnterprise(host) {
+		return client, nil
+	}
+
+	endpoints := HostEndpoints(host)
+	return client.WithEnterpriseURLs(endpoints.REST, endpoints.Uploads)
 }
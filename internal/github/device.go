@@ -0,0 +1,149 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OAuthClientID is the client ID of town's OAuth app on github.com, used for
+// the device flow. Release builds set it from the TOWN_OAUTH_CLIENT_ID
+// variable with
+// -ldflags "-X github.com/lordzsolt/town/internal/github.OAuthClientID=<id>".
+// oauth_client_id in the config overrides it.
+var OAuthClientID = ""
+
+// DefaultOAuthClientID returns the OAuth app to use on host when
+// oauth_client_id isn't set. town's own app only exists on github.com, so
+// GitHub Enterprise Server needs an app registered on the server.
+func DefaultOAuthClientID(host string) string {
+	if NormalizeHost(host) == defaultHost {
+		return OAuthClientID
+	}
+	return ""
+}
+
+// deviceScopes are the classic OAuth scopes town needs:
+// repository contents (for CODEOWNERS) and org/team membership
+const deviceScopes = "repo read:org"
+
+// defaultPollInterval is the polling interval when the server sends none,
+// and the increase asked for by slow_down, as RFC 8628 specifies
+const defaultPollInterval = 5 * time.Second
+
+// DeviceCode is shown to the user to authorize town in the browser
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// deviceResponse covers both successful and pending access token responses
+type deviceResponse struct {
+	AccessToken string `json:"access_token"`
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+	Interval    int    `json:"interval"`
+}
+
+// RequestDeviceCode starts the OAuth device authorization flow on host
+func RequestDeviceCode(ctx context.Context, host, clientID string) (*DeviceCode, error) {
+	if clientID == "" {
+		if NormalizeHost(host) != defaultHost {
+			return nil, fmt.Errorf("no OAuth client ID configured for %s: register an OAuth app with device flow enabled on the server and set oauth_client_id in the config", host)
+		}
+		return nil, errors.New("no OAuth client ID configured: this build has no default, set oauth_client_id in the config")
+	}
+
+	var code DeviceCode
+	err := postForm(ctx, HostEndpoints(host).Web+"login/device/code", url.Values{
+		"client_id": {clientID},
+		"scope":     {deviceScopes},
+	}, &code)
+	if err != nil {
+		return nil, fmt.Errorf("requesting device code: %w", err)
+	}
+	if code.DeviceCode == "" {
+		return nil, errors.New("requesting device code: GitHub returned no code, is the OAuth app's device flow enabled?")
+	}
+
+	return &code, nil
+}
+
+// PollDeviceToken waits until the user has authorized the device code and
+// returns the access token
+func PollDeviceToken(ctx context.Context, host, clientID string, code *DeviceCode) (string, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+
+		var resp deviceResponse
+		err := postForm(ctx, HostEndpoints(host).Web+"login/oauth/access_token", url.Values{
+			"client_id":   {clientID},
+			"device_code": {code.DeviceCode},
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		}, &resp)
+		if err != nil {
+			return "", fmt.Errorf("polling for token: %w", err)
+		}
+
+		switch resp.Error {
+		case "":
+			return resp.AccessToken, nil
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			// GitHub asks for the interval to be increased by 5 seconds
+			if resp.Interval > 0 {
+				interval = time.Duration(resp.Interval) * time.Second
+			} else {
+				interval += defaultPollInterval
+			}
+		case "expired_token":
+			return "", errors.New("the code expired, please try again")
+		case "access_denied":
+			return "", errors.New("authorization was denied")
+		default:
+			return "", fmt.Errorf("%s: %s", resp.Error, resp.Description)
+		}
+	}
+
+	return "", errors.New("the code expired, please try again")
+}
+
+func postForm(ctx context.Context, endpoint string, values url.Values, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
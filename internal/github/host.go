@@ -0,0 +1,49 @@
+package github
+
+import "strings"
+
+const defaultHost = "github.com"
+
+// Endpoints are the URLs of a GitHub host
+type Endpoints struct {
+	Web     string
+	REST    string
+	Uploads string
+}
+
+// NormalizeHost turns user input like "https://ghe.example.com/" into a bare
+// hostname. An empty host means github.com.
+func NormalizeHost(host string) string {
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	host = strings.TrimSuffix(host, "/")
+	host = strings.ToLower(host)
+	if host == "" || host == "api.github.com" {
+		return defaultHost
+	}
+	return host
+}
+
+// IsEnterprise reports whether host is a GitHub Enterprise Server instance
+func IsEnterprise(host string) bool {
+	return NormalizeHost(host) != defaultHost
+}
+
+// HostEndpoints returns the URLs of github.com or a GitHub Enterprise Server
+func HostEndpoints(host string) Endpoints {
+	host = NormalizeHost(host)
+	if host == defaultHost {
+		return Endpoints{
+			Web:     "https://github.com/",
+			REST:    "https://api.github.com/",
+			Uploads: "https://uploads.github.com/",
+		}
+	}
+
+	base := "https://" + host + "/"
+	return Endpoints{
+		Web:     base,
+		REST:    base + "api/v3/",
+		Uploads: base + "api/uploads/",
+	}
+}
@@ -0,0 +1,55 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v58/github"
+)
+
+// FetchOrgMembers returns the logins of all members of the organization.
+func FetchOrgMembers(ctx context.Context, client *github.Client, org string) ([]string, error) {
+	var logins []string
+
+	opts := &github.ListMembersOptions{ListOptions: github.ListOptions{PerPage: 100}}
+
+	for {
+		members, resp, err := client.Organizations.ListMembers(ctx, org, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range members {
+			logins = append(logins, m.GetLogin())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return logins, nil
+}
+
+// FetchUserTeams returns the role ("member" or "maintainer") of the user in
+// each of the teams they belong to, by team slug. GitHub counts members of
+// child teams as members of the parent team too. Every team is checked
+// separately, so this takes one request per team.
+func FetchUserTeams(ctx context.Context, client *github.Client, org, login string, teams []*github.Team) (map[string]string, error) {
+	roles := make(map[string]string)
+	for _, team := range teams {
+		membership, resp, err := client.Teams.GetTeamMembershipBySlug(ctx, org, team.GetSlug(), login)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				continue // Not a member
+			}
+			return nil, fmt.Errorf("checking membership in %s: %w", team.GetSlug(), err)
+		}
+		if membership.GetState() == "active" {
+			roles[team.GetSlug()] = membership.GetRole()
+		}
+	}
+	return roles, nil
+}
@@ -3,8 +3,11 @@ package github
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
+	"github.com/lordzsolt/town/internal/cache"
+
 	"github.com/google/go-github/v58/github"
 )
 
@@ -42,6 +45,12 @@ func FetchAllRepos(ctx context.Context, client *github.Client, org string) ([]*g
 }
 
 func getCodeownersContent(ctx context.Context, client *github.Client, owner, repo string) (string, error) {
+	_, content, err := getCodeownersFile(ctx, client, owner, repo)
+	return content, err
+}
+
+// getCodeownersFile returns the path and content of the first CODEOWNERS file found
+func getCodeownersFile(ctx context.Context, client *github.Client, owner, repo string) (string, string, error) {
 	for _, path := range codeownersLocations {
 		content, _, _, err := client.Repositories.GetContents(ctx, owner, repo, path, nil)
 		if err != nil {
@@ -51,22 +60,43 @@ func getCodeownersContent(ctx context.Context, client *github.Client, owner, rep
 		if content != nil {
 			decoded, err := content.GetContent()
 			if err != nil {
-				return "", err
+				return "", "", err
 			}
-			return decoded, nil
+			return path, decoded, nil
+		}
+	}
+
+	return "", "", nil // No CODEOWNERS found
+}
+
+// mentionsTeam reports whether the CODEOWNERS content mentions any of the teams
+func mentionsTeam(content string, teams []string) bool {
+	content = strings.ToLower(content)
+	for _, team := range teams {
+		if strings.Contains(content, strings.ToLower(team)) {
+			return true
 		}
 	}
+	return false
+}
 
-	return "", nil // No CODEOWNERS found
+// describeTeams describes the teams searched for: the team itself, followed by its child teams
+func describeTeams(teams []string) string {
+	if len(teams) == 1 {
+		return fmt.Sprintf("team '%s'", teams[0])
+	}
+	return fmt.Sprintf("team '%s' and %d child teams", teams[0], len(teams)-1)
 }
 
-func FetchReposWithTeamInCodeowners(ctx context.Context, client *github.Client, org string, team string) ([]*github.Repository, error) {
+// FetchReposWithTeamInCodeowners returns the repos whose CODEOWNERS mention
+// any of the teams, e.g. a team and its child teams
+func FetchReposWithTeamInCodeowners(ctx context.Context, client *github.Client, org string, teams []string) ([]*github.Repository, error) {
 	repos, err := FetchAllRepos(ctx, client, org)
 	if err != nil {
 		return nil, fmt.Errorf("fetching repos: %w", err)
 	}
 
-	fmt.Printf("Scanning %d repositories for team '%s'...\n\n", len(repos), team)
+	fmt.Printf("Scanning %d repositories for %s...\n\n", len(repos), describeTeams(teams))
 
 	var results []*github.Repository
 
@@ -84,7 +114,7 @@ func FetchReposWithTeamInCodeowners(ctx context.Context, client *github.Client,
 			continue // No CODEOWNERS file
 		}
 
-		if !strings.Contains(strings.ToLower(content), strings.ToLower(team)) {
+		if !mentionsTeam(content, teams) {
 			continue
 		}
 
@@ -132,6 +162,77 @@ func FetchReposWithoutCodeowners(ctx context.Context, client *github.Client, org
 	return results, nil
 }
 
+// FilterReposWithTeamInCodeowners is the offline counterpart of FetchReposWithTeamInCodeowners,
+// reading CODEOWNERS files from a snapshot instead of the API
+func FilterReposWithTeamInCodeowners(snap *cache.Snapshot, teams []string) []*github.Repository {
+	fmt.Printf("Scanning %d repositories for %s...\n\n", len(snap.Repos), describeTeams(teams))
+
+	var results []*github.Repository
+
+	for _, repo := range snap.Repos {
+		if repo.GetArchived() {
+			continue // Skip archived repos
+		}
+
+		content := snap.CodeownersContent(repo.GetName())
+		if content == "" || !mentionsTeam(content, teams) {
+			continue
+		}
+
+		printRepoDetails(repo)
+		results = append(results, repo)
+	}
+
+	printRepoCount(results)
+
+	return results
+}
+
+// FilterReposWithoutCodeowners is the offline counterpart of FetchReposWithoutCodeowners
+func FilterReposWithoutCodeowners(snap *cache.Snapshot) []*github.Repository {
+	fmt.Printf("Scanning %d repositories for missing CODEOWNERS...\n\n", len(snap.Repos))
+
+	var results []*github.Repository
+
+	for _, repo := range snap.Repos {
+		if repo.GetArchived() {
+			continue // Skip archived repos
+		}
+
+		if snap.CodeownersContent(repo.GetName()) != "" {
+			continue // Has CODEOWNERS, skip
+		}
+
+		printRepoDetails(repo)
+		results = append(results, repo)
+	}
+
+	printRepoCount(results)
+
+	return results
+}
+
+// FetchCodeowners returns the CODEOWNERS file of every repository that has
+// one, by repository name. Archived repositories are left out, like in the
+// repos command.
+func FetchCodeowners(ctx context.Context, client *github.Client, org string, repos []*github.Repository) map[string]*cache.CodeownersFile {
+	// Progress goes to stderr, so generated files can be redirected
+	fmt.Fprintf(os.Stderr, "Reading CODEOWNERS of %d repositories...\n", len(repos))
+
+	files := make(map[string]*cache.CodeownersFile)
+	for _, repo := range repos {
+		if repo.GetArchived() {
+			continue
+		}
+		path, content, err := getCodeownersFile(ctx, client, org, repo.GetName())
+		if err != nil || content == "" {
+			continue // No CODEOWNERS, or we can't access it
+		}
+		files[repo.GetName()] = &cache.CodeownersFile{Path: path, Content: content}
+	}
+	return files
+}
+
 func printRepoCount(results []*github.Repository) {
 	fmt.Println()
 	fmt.Printf("\nTotal: %d repositories\n", len(results))
@@ -0,0 +1,79 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lordzsolt/town/internal/cache"
+
+	"github.com/google/go-github/v58/github"
+)
+
+// FetchSnapshot downloads everything town knows how to query about an
+// organization: repositories, teams, memberships, team repository
+// permissions and the CODEOWNERS file of every repository.
+func FetchSnapshot(ctx context.Context, client *github.Client, org string) (*cache.Snapshot, error) {
+	snap := &cache.Snapshot{
+		Org:        org,
+		Members:    make(map[string][]*cache.TeamMember),
+		TeamRepos:  make(map[string]map[string]string),
+		Codeowners: make(map[string]*cache.CodeownersFile),
+	}
+
+	var err error
+
+	fmt.Println("Fetching repositories...")
+	if snap.Repos, err = FetchAllRepos(ctx, client, org); err != nil {
+		return nil, fmt.Errorf("fetching repos: %w", err)
+	}
+
+	fmt.Println("Fetching teams...")
+	if snap.Teams, err = FetchAllTeams(ctx, client, org); err != nil {
+		return nil, fmt.Errorf("fetching teams: %w", err)
+	}
+
+	fmt.Println("Fetching organization members...")
+	if snap.OrgMembers, err = FetchOrgMembers(ctx, client, org); err != nil {
+		return nil, fmt.Errorf("fetching members: %w", err)
+	}
+
+	fmt.Printf("Fetching memberships and permissions of %d teams...\n", len(snap.Teams))
+	for _, team := range snap.Teams {
+		slug := team.GetSlug()
+
+		// Fetch maintainers and members separately, the "all" role doesn't report roles
+		for _, role := range []string{"maintainer", "member"} {
+			users, err := FetchTeamMembers(ctx, client, org, slug, role)
+			if err != nil {
+				return nil, fmt.Errorf("fetching members of %s: %w", slug, err)
+			}
+			for _, u := range users {
+				snap.Members[slug] = append(snap.Members[slug], &cache.TeamMember{Login: u.GetLogin(), Role: role})
+			}
+		}
+
+		repos, err := FetchTeamRepos(ctx, client, org, slug)
+		if err != nil {
+			return nil, fmt.Errorf("fetching repos of %s: %w", slug, err)
+		}
+		perms := make(map[string]string, len(repos))
+		for _, r := range repos {
+			perms[r.GetName()] = Permission(r)
+		}
+		snap.TeamRepos[slug] = perms
+	}
+
+	fmt.Printf("Fetching CODEOWNERS of %d repositories...\n", len(snap.Repos))
+	for _, repo := range snap.Repos {
+		path, content, err := getCodeownersFile(ctx, client, org, repo.GetName())
+		if err != nil || content == "" {
+			continue // No CODEOWNERS, or we can't access it
+		}
+		snap.Codeowners[repo.GetName()] = &cache.CodeownersFile{Path: path, Content: content}
+	}
+
+	snap.SyncedAt = time.Now().Format(time.RFC3339)
+
+	return snap, nil
+}
@@ -3,6 +3,7 @@ package github
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/google/go-github/v58/github"
 )
@@ -40,3 +41,116 @@ func PrintTeams(teams []*github.Team, org string) {
 	}
 	fmt.Printf("Total: %d teams\n", len(teams))
 }
+
+// FetchTeamMembers returns the members of a team with the given role
+// ("all", "member" or "maintainer").
+func FetchTeamMembers(ctx context.Context, client *github.Client, org, slug, role string) ([]*github.User, error) {
+	var allMembers []*github.User
+
+	opts := &github.TeamListTeamMembersOptions{
+		Role:        role,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		members, resp, err := client.Teams.ListTeamMembersBySlug(ctx, org, slug, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		allMembers = append(allMembers, members...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allMembers, nil
+}
+
+// FetchMyTeams returns the teams of the authenticated user in org
+func FetchMyTeams(ctx context.Context, client *github.Client, org string) ([]*github.Team, error) {
+	var myTeams []*github.Team
+
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		teams, resp, err := client.Teams.ListUserTeams(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, team := range teams {
+			if strings.EqualFold(team.GetOrganization().GetLogin(), org) {
+				myTeams = append(myTeams, team)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return myTeams, nil
+}
+
+// FetchChildTeams returns the teams directly nested below a team
+func FetchChildTeams(ctx context.Context, client *github.Client, org, slug string) ([]*github.Team, error) {
+	var allTeams []*github.Team
+
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		teams, resp, err := client.Teams.ListChildTeamsByParentSlug(ctx, org, slug, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		allTeams = append(allTeams, teams...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allTeams, nil
+}
+
+// FetchTeamRepos returns the repositories a team has explicit permissions on.
+// The team's permissions are available via Repository.Permissions.
+func FetchTeamRepos(ctx context.Context, client *github.Client, org, slug string) ([]*github.Repository, error) {
+	var allRepos []*github.Repository
+
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		repos, resp, err := client.Teams.ListTeamReposBySlug(ctx, org, slug, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		allRepos = append(allRepos, repos...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allRepos, nil
+}
+
+// Permission returns the highest permission in a repository's permission map,
+// e.g. "admin" or "push". Returns "" if no permission is granted.
+func Permission(repo *github.Repository) string {
+	perms := repo.GetPermissions()
+	for _, p := range []string{"admin", "maintain", "push", "triage", "pull"} {
+		if perms[p] {
+			return p
+		}
+	}
+	return ""
+}
@@ -1,10 +1,18 @@
 package github
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"slices"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/zalando/go-keyring"
 	"golang.org/x/term"
@@ -12,13 +20,200 @@ import (
 
 const (
 	keyringService = "town-github-token"
-	keyringUser    = "github-token"
+	// keyringUser holds the default github.com token, used when no org specific one exists
+	keyringUser = "github-token"
+	// credentialHelperTimeout bounds how long a credential helper may take
+	// before the next token source is tried
+	credentialHelperTimeout = 5 * time.Second
 )
 
-// promptForToken asks the user to enter their GitHub token
-func promptForToken() (string, error) {
-	fmt.Print(`GitHub token not found. 
-Please visit https://github.com/settings/personal-access-tokens to create a new token.
+// Token sources, in the order they are tried
+const (
+	SourceFlag       = "--token flag"
+	SourceEnv        = "environment variable"
+	SourceGhCLI      = "gh CLI"
+	SourceCredHelper = "git credential helper"
+	SourceKeyring    = "keyring"
+)
+
+// Token sources that can be selected with ClientOptions.TokenSource
+const (
+	TokenSourceAuto          = "auto"
+	TokenSourceEnv           = "env"
+	TokenSourceGh            = "gh"
+	TokenSourceGitCredential = "git-credential"
+	TokenSourceKeyring       = "keyring"
+)
+
+// TokenSources lists the valid values of ClientOptions.TokenSource
+var TokenSources = []string{TokenSourceAuto, TokenSourceEnv, TokenSourceGh, TokenSourceGitCredential, TokenSourceKeyring}
+
+// tokenEnvVars are checked in order for a github.com token
+var tokenEnvVars = []string{"TOWN_GITHUB_TOKEN", "GITHUB_TOKEN", "GH_TOKEN"}
+
+// enterpriseTokenEnvVars are checked in order for a GitHub Enterprise Server
+// token, following the GitHub CLI's conventions
+var enterpriseTokenEnvVars = []string{"TOWN_GITHUB_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+
+func envVarsFor(host string) []string {
+	if IsEnterprise(host) {
+		return enterpriseTokenEnvVars
+	}
+	return tokenEnvVars
+}
+
+// Token is a GitHub token together with where it was found
+type Token struct {
+	Value  string
+	Source string
+	// Detail further describes the source, e.g. the environment variable name
+	Detail string
+}
+
+// Describe returns a human readable description of the token's source
+func (t *Token) Describe() string {
+	if t.Detail != "" {
+		return fmt.Sprintf("%s (%s)", t.Source, t.Detail)
+	}
+	return t.Source
+}
+
+// keyringAccount returns the keyring account a token is stored under.
+// Tokens are stored per host and org, e.g. "github.com/myorg"; an empty org
+// selects the host's default token.
+func keyringAccount(host, org string) string {
+	if org != "" {
+		return host + "/" + org
+	}
+	if host == defaultHost {
+		return keyringUser // Kept for tokens stored by earlier versions
+	}
+	return host
+}
+
+// LookupToken tries every non-interactive token source for opts.Host in order:
+//  1. the --token flag (opts.Token)
+//  2. TOWN_GITHUB_TOKEN, GITHUB_TOKEN or GH_TOKEN
+//     (GH_ENTERPRISE_TOKEN or GITHUB_ENTERPRISE_TOKEN instead of the latter
+//     two for GitHub Enterprise Server)
+//  3. the org's token in the keyring, as it is more specific than the rest
+//  4. `gh auth token`
+//  5. a configured git credential helper
+//  6. the host's default token in the keyring
+//
+// If opts.TokenSource is set to anything but auto, only the flag and that
+// source are tried. Returns nil, nil if no token was found.
+func LookupToken(opts ClientOptions) (*Token, error) {
+	host := NormalizeHost(opts.Host)
+
+	if opts.Token != "" {
+		return &Token{Value: opts.Token, Source: SourceFlag}, nil
+	}
+
+	source := opts.TokenSource
+	if source == "" {
+		source = TokenSourceAuto
+	}
+	if !slices.Contains(TokenSources, source) {
+		return nil, fmt.Errorf("invalid token source '%s': use one of %s", source, strings.Join(TokenSources, ", "))
+	}
+	use := func(s string) bool {
+		return source == TokenSourceAuto || source == s
+	}
+
+	if use(TokenSourceEnv) {
+		for _, name := range envVarsFor(host) {
+			if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+				return &Token{Value: value, Source: SourceEnv, Detail: name}, nil
+			}
+		}
+	}
+
+	if use(TokenSourceKeyring) && opts.Org != "" {
+		if token, account := StoredOrgToken(host, opts.Org); token != "" {
+			return &Token{Value: token, Source: SourceKeyring, Detail: account}, nil
+		}
+	}
+
+	if use(TokenSourceGh) {
+		if token := tokenFromGhCLI(host); token != "" {
+			return &Token{Value: token, Source: SourceGhCLI}, nil
+		}
+	}
+
+	if use(TokenSourceGitCredential) {
+		if token := tokenFromCredentialHelper(host); token != "" {
+			return &Token{Value: token, Source: SourceCredHelper}, nil
+		}
+	}
+
+	if use(TokenSourceKeyring) {
+		if token, account := StoredOrgToken(host, ""); token != "" {
+			return &Token{Value: token, Source: SourceKeyring, Detail: account}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// tokenFromGhCLI returns the token of the GitHub CLI, if it is installed and logged in
+func tokenFromGhCLI(host string) string {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return ""
+	}
+
+	out, err := exec.Command("gh", "auth", "token", "--hostname", host).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// tokenFromCredentialHelper asks git's configured credential helpers for
+// a password for host, without ever prompting the user.
+func tokenFromCredentialHelper(host string) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		return ""
+	}
+
+	// Helpers like Git Credential Manager may open a window or wait for a
+	// login unless told not to, so they are also given a deadline
+	ctx, cancel := context.WithTimeout(context.Background(), credentialHelperTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=" + host + "\n\n")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=", "GCM_INTERACTIVE=never")
+	// The helper git started may keep the output open after git is killed
+	cmd.WaitDelay = time.Second
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if password, ok := strings.CutPrefix(scanner.Text(), "password="); ok {
+			return strings.TrimSpace(password)
+		}
+	}
+	return ""
+}
+
+// PromptForToken asks the user to enter their GitHub token for host
+func PromptForToken(host string) (string, error) {
+	web := HostEndpoints(host).Web
+	if IsEnterprise(host) {
+		// Fine-grained tokens aren't available on older GitHub Enterprise Server versions
+		fmt.Printf(`Please visit %ssettings/tokens to create a new classic token.
+
+Select the scopes:
+- repo
+- read:org
+
+`, web)
+	} else {
+		fmt.Printf(`Please visit %ssettings/personal-access-tokens to create a new token.
 
 Select:
 - Resource owner: Your organization
@@ -28,7 +223,9 @@ Permissions
 - Repository permissions: Contents (read-only)
 - Organization permissions: Members (read-only)
 
-Please enter your GitHub personal access token: `)
+`, web)
+	}
+	fmt.Print("Please enter your GitHub personal access token: ")
 
 	// Read password without echoing
 	tokenBytes, err := term.ReadPassword(int(syscall.Stdin))
@@ -46,27 +243,85 @@ Please enter your GitHub personal access token: `)
 	return token, nil
 }
 
-// getToken retrieves the GitHub token from keyring or prompts the user
-func getToken() (string, error) {
-	// Try keyring first
-	token, err := keyring.Get(keyringService, keyringUser)
-	if err == nil && token != "" {
-		return token, nil
+// getToken retrieves the GitHub token from the first available source,
+// prompting the user only if stdin is a terminal and the keyring may be used
+func getToken(opts ClientOptions) (string, error) {
+	token, err := LookupToken(opts)
+	if err != nil {
+		return "", err
+	}
+	if token != nil {
+		return token.Value, nil
+	}
+
+	host := NormalizeHost(opts.Host)
+	if opts.TokenSource != "" && opts.TokenSource != TokenSourceAuto && opts.TokenSource != TokenSourceKeyring {
+		return "", fmt.Errorf("GitHub token not found in token source '%s'", opts.TokenSource)
+	}
+
+	if !term.IsTerminal(int(syscall.Stdin)) {
+		return "", fmt.Errorf("GitHub token not found: use --token, set %s, or log in with 'gh auth login'",
+			strings.Join(envVarsFor(host), ", "))
 	}
 
 	// Prompt user for token
-	token, err = promptForToken()
+	fmt.Println("GitHub token not found. Tip: 'town auth login --web' logs you in through the browser.")
+	value, err := PromptForToken(host)
 	if err != nil {
 		return "", err
 	}
 
 	// Store in keyring for future use
-	if err := keyring.Set(keyringService, keyringUser, token); err != nil {
+	if err := StoreToken(value, host, ""); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: could not store token in keyring: %v\n", err)
 		// Continue anyway since we have the token
 	} else {
 		fmt.Println("Token stored in keyring for future use.")
 	}
 
+	return value, nil
+}
+
+// ReadToken reads a token from r, e.g. when piped via stdin
+func ReadToken(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token: %w", err)
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token cannot be empty")
+	}
 	return token, nil
 }
+
+// StoredOrgToken returns the keyring token stored for exactly host and org,
+// or the host's default token if org is empty, together with the account it
+// is stored under. Unlike LookupToken, it doesn't fall back to the default
+// token. Returns "", "" if there is none. A missing or locked keyring (e.g.
+// in containers) is not an error.
+func StoredOrgToken(host, org string) (string, string) {
+	account := keyringAccount(NormalizeHost(host), org)
+	token, err := keyring.Get(keyringService, account)
+	if err != nil || token == "" {
+		return "", ""
+	}
+	return token, account
+}
+
+// StoreToken saves the token in the keyring for host and org, or as the
+// host's default token if org is empty, replacing any previous one
+func StoreToken(token, host, org string) error {
+	return keyring.Set(keyringService, keyringAccount(NormalizeHost(host), org), token)
+}
+
+// DeleteToken removes the token of host and org, or the host's default
+// token if org is empty, from the keyring. Returns false if there was no token to delete.
+func DeleteToken(host, org string) (bool, error) {
+	err := keyring.Delete(keyringService, keyringAccount(NormalizeHost(host), org))
+	if errors.Is(err, keyring.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
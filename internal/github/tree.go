@@ -0,0 +1,141 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/go-github/v58/github"
+)
+
+// TeamNode is a team together with its child teams
+type TeamNode struct {
+	Team     *github.Team
+	Children []*TeamNode
+}
+
+// BuildTeamTree arranges teams by their parent team. Returns the top-level
+// teams, sorted by slug. Teams whose parent isn't in teams are treated as top-level.
+func BuildTeamTree(teams []*github.Team) []*TeamNode {
+	nodes := make(map[string]*TeamNode, len(teams))
+	for _, team := range teams {
+		nodes[team.GetSlug()] = &TeamNode{Team: team}
+	}
+
+	var roots []*TeamNode
+	for _, team := range teams {
+		node := nodes[team.GetSlug()]
+		if parent, ok := nodes[team.GetParent().GetSlug()]; team.Parent != nil && ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	sortTeamNodes(roots)
+	return roots
+}
+
+func sortTeamNodes(nodes []*TeamNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Team.GetSlug() < nodes[j].Team.GetSlug()
+	})
+	for _, node := range nodes {
+		sortTeamNodes(node.Children)
+	}
+}
+
+// TeamDescendants returns the slugs of all teams nested below slug, at any depth
+func TeamDescendants(teams []*github.Team, slug string) []string {
+	children := make(map[string][]string)
+	for _, team := range teams {
+		if team.Parent != nil {
+			parent := team.GetParent().GetSlug()
+			children[parent] = append(children[parent], team.GetSlug())
+		}
+	}
+
+	var descendants []string
+	queue := children[slug]
+	seen := map[string]bool{slug: true}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		descendants = append(descendants, next)
+		queue = append(queue, children[next]...)
+	}
+
+	sort.Strings(descendants)
+	return descendants
+}
+
+// TeamAncestors returns the slugs of the teams slug is nested in, starting
+// with its parent
+func TeamAncestors(teams []*github.Team, slug string) []string {
+	parents := make(map[string]string)
+	for _, team := range teams {
+		if team.Parent != nil {
+			parents[team.GetSlug()] = team.GetParent().GetSlug()
+		}
+	}
+
+	var ancestors []string
+	seen := map[string]bool{slug: true}
+	for parent, ok := parents[slug]; ok && !seen[parent]; parent, ok = parents[parent] {
+		seen[parent] = true
+		ancestors = append(ancestors, parent)
+	}
+	return ancestors
+}
+
+// TeamCounts are the number of members and repositories of a team
+type TeamCounts struct {
+	Members int
+	Repos   int
+}
+
+// PrintTeamTree prints the team hierarchy with the counts of each team by slug
+func PrintTeamTree(roots []*TeamNode, org string, counts map[string]TeamCounts) {
+	fmt.Printf("Teams in organization '%s':\n\n", org)
+
+	total := 0
+	var printNodes func(nodes []*TeamNode, prefix string, top bool)
+	printNodes = func(nodes []*TeamNode, prefix string, top bool) {
+		for i, node := range nodes {
+			total++
+			branch, indent := "", ""
+			if !top {
+				branch, indent = "├── ", "│   "
+				if i == len(nodes)-1 {
+					branch, indent = "└── ", "    "
+				}
+			}
+
+			slug := node.Team.GetSlug()
+			c := counts[slug]
+			fmt.Printf("%s%s%s (%d members, %d repos)\n", prefix, branch, slug, c.Members, c.Repos)
+			printNodes(node.Children, prefix+indent, false)
+		}
+	}
+	printNodes(roots, "", true)
+
+	fmt.Printf("\nTotal: %d teams\n", total)
+}
+
+// FetchTeamCounts returns the number of members and repositories of each
+// team by slug. The team list doesn't include them, so every team is fetched.
+func FetchTeamCounts(ctx context.Context, client *github.Client, org string, teams []*github.Team) (map[string]TeamCounts, error) {
+	counts := make(map[string]TeamCounts, len(teams))
+	for _, team := range teams {
+		full, _, err := client.Teams.GetTeamBySlug(ctx, org, team.GetSlug())
+		if err != nil {
+			return nil, fmt.Errorf("fetching team %s: %w", team.GetSlug(), err)
+		}
+		counts[team.GetSlug()] = TeamCounts{Members: full.GetMembersCount(), Repos: full.GetReposCount()}
+	}
+	return counts, nil
+}
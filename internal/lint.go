@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lordzsolt/town/internal/cache"
+	"github.com/lordzsolt/town/internal/codeowners"
+)
+
+// LintProblem is an owner in a CODEOWNERS file that GitHub ignores
+type LintProblem struct {
+	Repo    string
+	Path    string
+	Line    int
+	Owner   string
+	Problem string
+}
+
+// LintInput is the org data CODEOWNERS owners are checked against
+type LintInput struct {
+	Org string
+	// Files maps repository names to their CODEOWNERS file
+	Files      map[string]*cache.CodeownersFile
+	Teams      []string
+	OrgMembers []string
+	// TeamRepos maps team slugs to their permission on each repository.
+	// Write access isn't checked for teams missing from it.
+	TeamRepos map[string]map[string]string
+}
+
+// LintCodeowners checks every owner in the CODEOWNERS files: teams must exist
+// in the org and have write access to the repository, users must be members
+// of the org. Email owners can't be checked and are skipped.
+// Problems are sorted by repository and line.
+func LintCodeowners(in *LintInput) []*LintProblem {
+	teams := make(map[string]bool, len(in.Teams))
+	for _, slug := range in.Teams {
+		teams[strings.ToLower(slug)] = true
+	}
+	members := make(map[string]bool, len(in.OrgMembers))
+	for _, login := range in.OrgMembers {
+		members[strings.ToLower(login)] = true
+	}
+
+	var problems []*LintProblem
+	for repo, file := range in.Files {
+		for _, rule := range codeowners.Parse(file.Content) {
+			for _, owner := range rule.Owners {
+				problem := lintOwner(in, teams, members, repo, owner)
+				if problem == "" {
+					continue
+				}
+				problems = append(problems, &LintProblem{
+					Repo:    repo,
+					Path:    file.Path,
+					Line:    rule.Line,
+					Owner:   owner,
+					Problem: problem,
+				})
+			}
+		}
+	}
+
+	// Stable, so the owners of a line stay in the order they're listed
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Repo != problems[j].Repo {
+			return problems[i].Repo < problems[j].Repo
+		}
+		return problems[i].Line < problems[j].Line
+	})
+	return problems
+}
+
+// lintOwner returns what is wrong with an owner, "" if nothing is
+func lintOwner(in *LintInput, teams, members map[string]bool, repo, owner string) string {
+	switch codeowners.OwnerKind(owner) {
+	case "team":
+		slug, ok := codeowners.TeamSlug(owner, in.Org)
+		if !ok {
+			return fmt.Sprintf("team of another organization than '%s'", in.Org)
+		}
+		if !teams[slug] {
+			return "team doesn't exist (renamed or deleted?)"
+		}
+		perms, ok := in.TeamRepos[slug]
+		if ok && !writePermissions[perms[repo]] {
+			permission := perms[repo]
+			if permission == "" {
+				permission = "none"
+			}
+			return fmt.Sprintf("team has no write access (permission: %s)", permission)
+		}
+	case "user":
+		login, _ := codeowners.UserLogin(owner)
+		if !members[strings.ToLower(login)] {
+			return fmt.Sprintf("not a member of '%s' (left the organization?)", in.Org)
+		}
+	}
+	return ""
+}
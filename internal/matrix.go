@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"sort"
+
+	"github.com/lordzsolt/town/internal/cache"
+	"github.com/lordzsolt/town/internal/codeowners"
+)
+
+// MatrixCell is what a team owns of a repository
+type MatrixCell struct {
+	// Whole is true if a rule like `*` names the team
+	Whole bool
+	// Patterns are the patterns of the rules naming the team
+	Patterns []string
+}
+
+// OwnershipMatrix is a grid of the teams named in CODEOWNERS files versus
+// the repositories
+type OwnershipMatrix struct {
+	Repos []string
+	Teams []string
+	// cells maps repository names to team slugs to cells
+	cells map[string]map[string]*MatrixCell
+}
+
+// Cell returns what the team owns of the repository, nil if nothing
+func (m *OwnershipMatrix) Cell(repo, team string) *MatrixCell {
+	return m.cells[repo][team]
+}
+
+// BuildOwnershipMatrix builds the matrix of the org's teams named in the
+// CODEOWNERS files (by repository) and the repositories. Repositories owned by
+// no team are kept, so gaps in ownership show up.
+func BuildOwnershipMatrix(org string, repos []string, files map[string]*cache.CodeownersFile) *OwnershipMatrix {
+	m := &OwnershipMatrix{cells: make(map[string]map[string]*MatrixCell)}
+
+	teams := make(map[string]bool)
+	for _, repo := range repos {
+		m.Repos = append(m.Repos, repo)
+
+		file, ok := files[repo]
+		if !ok {
+			continue
+		}
+		cells := make(map[string]*MatrixCell)
+		for _, rule := range codeowners.Parse(file.Content) {
+			for _, slug := range codeowners.Teams([]*codeowners.Rule{rule}, org) {
+				cell, ok := cells[slug]
+				if !ok {
+					cell = &MatrixCell{}
+					cells[slug] = cell
+				}
+				cell.Whole = cell.Whole || codeowners.IsWholeRepo(rule.Pattern)
+				cell.Patterns = append(cell.Patterns, rule.Pattern)
+				teams[slug] = true
+			}
+		}
+		m.cells[repo] = cells
+	}
+
+	sort.Strings(m.Repos)
+	m.Teams = sortedKeys(teams, nil)
+	return m
+}
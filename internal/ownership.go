@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lordzsolt/town/internal/cache"
+	"github.com/lordzsolt/town/internal/codeowners"
+	gh "github.com/lordzsolt/town/internal/github"
+
+	"github.com/google/go-github/v58/github"
+)
+
+// CodeownersMatch is a repository whose CODEOWNERS file names an owner,
+// together with the patterns of the rules naming it
+type CodeownersMatch struct {
+	Repo     string
+	Patterns []string
+}
+
+// FindTeamOwnership returns the repositories whose CODEOWNERS name any of the
+// org's teams, sorted by name. files maps repository names to CODEOWNERS files.
+func FindTeamOwnership(files map[string]*cache.CodeownersFile, org string, slugs []string) []*CodeownersMatch {
+	wanted := make(map[string]bool, len(slugs))
+	for _, slug := range slugs {
+		wanted[strings.ToLower(slug)] = true
+	}
+
+	return findOwnership(files, func(owner string) bool {
+		slug, ok := codeowners.TeamSlug(owner, org)
+		return ok && wanted[slug]
+	})
+}
+
+// FindUserOwnership returns the repositories whose CODEOWNERS name the user
+// directly, sorted by name. Logins are compared case-insensitively.
+func FindUserOwnership(files map[string]*cache.CodeownersFile, login string) []*CodeownersMatch {
+	login = strings.TrimPrefix(login, "@")
+
+	return findOwnership(files, func(owner string) bool {
+		user, ok := codeowners.UserLogin(owner)
+		return ok && strings.EqualFold(user, login)
+	})
+}
+
+func findOwnership(files map[string]*cache.CodeownersFile, matches func(owner string) bool) []*CodeownersMatch {
+	var results []*CodeownersMatch
+
+	for repo, file := range files {
+		var patterns []string
+		for _, rule := range codeowners.Parse(file.Content) {
+			for _, owner := range rule.Owners {
+				if matches(owner) {
+					patterns = append(patterns, rule.Pattern)
+					break
+				}
+			}
+		}
+		if len(patterns) > 0 {
+			results = append(results, &CodeownersMatch{Repo: repo, Patterns: patterns})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Repo < results[j].Repo
+	})
+	return results
+}
+
+// UserTeam is a team a user belongs to
+type UserTeam struct {
+	Slug string
+	Role string // member or maintainer
+	// Via is the child team the membership comes from, "" for direct members
+	Via string
+}
+
+// TeamOwnedRepo is a repository owned through one or more teams
+type TeamOwnedRepo struct {
+	Repo  string
+	Teams []string
+}
+
+// UserOwnership describes the teams a user belongs to and the repositories
+// they own, directly or through their teams
+type UserOwnership struct {
+	Login        string
+	Teams        []*UserTeam
+	Direct       []*CodeownersMatch
+	ThroughTeams []*TeamOwnedRepo
+}
+
+// ResolveUserOwnership combines a user's team roles (by slug) with the org's
+// team hierarchy and CODEOWNERS files (by repository). roles are the
+// memberships as GitHub reports them, online and in snapshots alike: members
+// of a child team are members of its parent teams too. Unless the user
+// maintains it, a team they belong to through one of its children is
+// reported as such, with the team they were added to in Via.
+func ResolveUserOwnership(login, org string, teams []*github.Team, roles map[string]string, files map[string]*cache.CodeownersFile) *UserOwnership {
+	o := &UserOwnership{Login: login}
+
+	// viaChild maps teams to a child team the user belongs to
+	viaChild := make(map[string]string)
+	for _, slug := range sortedKeys(roles, nil) {
+		ancestors := gh.TeamAncestors(teams, slug)
+		if len(ancestors) == 0 {
+			continue
+		}
+		if _, ok := viaChild[ancestors[0]]; !ok {
+			viaChild[ancestors[0]] = slug
+		}
+	}
+
+	memberOf := make(map[string]*UserTeam)
+	for slug, role := range roles {
+		t := &UserTeam{Slug: slug, Role: role}
+		if role != "maintainer" {
+			// Follow the children down to the team the user was added to
+			for via, ok := viaChild[slug]; ok; via, ok = viaChild[via] {
+				t.Via = via
+				if roles[via] == "maintainer" {
+					break
+				}
+			}
+		}
+		memberOf[slug] = t
+	}
+	for _, t := range memberOf {
+		o.Teams = append(o.Teams, t)
+	}
+	sort.Slice(o.Teams, func(i, j int) bool {
+		return o.Teams[i].Slug < o.Teams[j].Slug
+	})
+
+	o.Direct = FindUserOwnership(files, login)
+
+	owners := make(map[string][]string)
+	for _, t := range o.Teams {
+		for _, m := range FindTeamOwnership(files, org, []string{t.Slug}) {
+			owners[m.Repo] = append(owners[m.Repo], t.Slug)
+		}
+	}
+	for _, repo := range sortedKeys(owners, nil) {
+		o.ThroughTeams = append(o.ThroughTeams, &TeamOwnedRepo{Repo: repo, Teams: owners[repo]})
+	}
+
+	return o
+}
@@ -0,0 +1,162 @@
+// Package paths resolves where town keeps its files, following the XDG Base
+// Directory Specification.
+package paths
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const appName = "town"
+
+// ConfigDir returns the config directory: $XDG_CONFIG_HOME/town, or
+// ~/.config/town if XDG_CONFIG_HOME is unset
+func ConfigDir() (string, error) {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// CacheDir returns the cache directory: $XDG_CACHE_HOME/town, or
+// ~/.cache/town if XDG_CACHE_HOME is unset
+func CacheDir() (string, error) {
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// LegacyDir returns ~/.town, where earlier versions kept both the config
+// and the cache
+func LegacyDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "."+appName), nil
+}
+
+// xdgDir returns town's directory in the base directory named by env,
+// falling back to the default below the home directory. Relative paths are
+// ignored, as the spec requires.
+func xdgDir(env, fallback string) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, fallback, appName), nil
+}
+
+// MigratedMarker is the file left in ~/.town once its files were migrated,
+// so the migration isn't attempted on every run
+const MigratedMarker = ".migrated"
+
+// Move records a file or directory moved by MigrateLegacy
+type Move struct {
+	From string
+	To   string
+}
+
+// MigrateLegacy moves the config file and the cache from ~/.town to the XDG
+// directories. configFiles are the config file names, in the order they are
+// looked up: only the first one found is moved, as that's the one in use.
+// Nothing is moved if the destination already exists. ~/.town is removed if
+// it ends up empty, otherwise a marker file records that it was migrated.
+func MigrateLegacy(configFiles []string) ([]Move, error) {
+	legacyDir, err := LegacyDir()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(legacyDir); err != nil {
+		return nil, nil // Nothing to migrate
+	}
+	if _, err := os.Stat(filepath.Join(legacyDir, MigratedMarker)); err == nil {
+		return nil, nil // Already migrated, the rest is left for the user
+	}
+
+	configDir, err := ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	cacheDir, err := CacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []Move{{From: filepath.Join(legacyDir, "cache"), To: cacheDir}}
+	if firstExisting(configDir, configFiles) == "" {
+		// A config file in any format means the config was already migrated
+		if name := firstExisting(legacyDir, configFiles); name != "" {
+			candidates = append(candidates, Move{From: filepath.Join(legacyDir, name), To: filepath.Join(configDir, name)})
+		}
+	}
+
+	var moves []Move
+	for _, m := range candidates {
+		if _, err := os.Stat(m.From); err != nil {
+			continue
+		}
+		if _, err := os.Stat(m.To); err == nil {
+			continue // Already migrated or created by a newer version
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return moves, err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(m.To), 0755); err != nil {
+			return moves, err
+		}
+		if err := move(m.From, m.To); err != nil {
+			return moves, err
+		}
+		moves = append(moves, m)
+	}
+
+	// Only removes the directory if it is empty
+	if err := os.Remove(legacyDir); err != nil {
+		os.WriteFile(filepath.Join(legacyDir, MigratedMarker), nil, 0644)
+	}
+	return moves, nil
+}
+
+// firstExisting returns the first of names that exists in dir, "" if none
+func firstExisting(dir string, names []string) string {
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// move renames a file or directory, copying it and removing the original if
+// the destination is on another file system
+func move(from, to string) error {
+	err := os.Rename(from, to)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	info, err := os.Stat(from)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		err = os.CopyFS(to, os.DirFS(from))
+	} else {
+		err = copyFile(from, to, info.Mode().Perm())
+	}
+	if err != nil {
+		os.RemoveAll(to)
+		return err
+	}
+	return os.RemoveAll(from)
+}
+
+// copyFile copies a file's content to a new file with the given permissions
+func copyFile(from, to string, perm os.FileMode) error {
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(to, data, perm)
+}
@@ -0,0 +1,258 @@
+// Package picker implements searchable single and multiple choice lists for
+// interactive terminals.
+package picker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lordzsolt/town/internal/fuzzy"
+
+	"golang.org/x/term"
+)
+
+// ErrCanceled is returned when the user cancels with Esc or Ctrl-C
+var ErrCanceled = errors.New("canceled")
+
+// maxVisible is the number of items shown at once
+const maxVisible = 10
+
+// IsInteractive reports whether stdin and stdout are terminals, so a picker can be shown
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Pick lets the user choose one of items. Typing filters the items with fuzzy
+// matching, arrow keys move and Enter selects.
+func Pick(title string, items []string) (string, error) {
+	selected, err := run(title, items, false)
+	if err != nil {
+		return "", err
+	}
+	return selected[0], nil
+}
+
+// PickMany lets the user choose any number of items. Tab toggles an item,
+// Ctrl-A toggles all visible items and Enter confirms. If nothing was
+// toggled, the highlighted item is chosen.
+func PickMany(title string, items []string) ([]string, error) {
+	return run(title, items, true)
+}
+
+// state is a picker being shown
+type state struct {
+	title    string
+	items    []string
+	multi    bool
+	query    string
+	matches  []string
+	cursor   int
+	offset   int
+	selected map[string]bool
+	// lines is the number of lines drawn last, to clear them on redraw
+	lines int
+}
+
+func run(title string, items []string, multi bool) ([]string, error) {
+	if len(items) == 0 {
+		return nil, errors.New("nothing to choose from")
+	}
+	if !IsInteractive() {
+		return nil, errors.New("a terminal is needed to choose interactively")
+	}
+
+	fd := int(os.Stdin.Fd())
+	old, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	defer term.Restore(fd, old)
+
+	s := &state{title: title, items: items, multi: multi, selected: make(map[string]bool)}
+	s.filter()
+	s.draw(os.Stdout)
+	defer s.clear(os.Stdout)
+
+	buf := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		switch key := string(buf[:n]); key {
+		case "\x03", "\x1b": // Ctrl-C, Esc
+			return nil, ErrCanceled
+		case "\r", "\n":
+			if result := s.result(); len(result) > 0 {
+				return result, nil
+			}
+		case "\x1b[A", "\x10": // Up, Ctrl-P
+			s.move(-1)
+		case "\x1b[B", "\x0e": // Down, Ctrl-N
+			s.move(1)
+		case "\t":
+			if multi && len(s.matches) > 0 {
+				item := s.matches[s.cursor]
+				s.selected[item] = !s.selected[item]
+				s.move(1)
+			}
+		case "\x01": // Ctrl-A
+			if multi {
+				s.toggleAll()
+			}
+		case "\x7f", "\b": // Backspace
+			if s.query != "" {
+				runes := []rune(s.query)
+				s.query = string(runes[:len(runes)-1])
+				s.filter()
+			}
+		default:
+			if key[0] >= ' ' && key[0] != 0x7f && !strings.HasPrefix(key, "\x1b") {
+				s.query += key
+				s.filter()
+			}
+		}
+		s.draw(os.Stdout)
+	}
+}
+
+// filter updates the matches after the query changed
+func (s *state) filter() {
+	s.matches = fuzzy.Rank(s.query, s.items)
+	s.cursor, s.offset = 0, 0
+}
+
+// move moves the cursor by delta, scrolling if needed
+func (s *state) move(delta int) {
+	if len(s.matches) == 0 {
+		return
+	}
+	s.cursor = (s.cursor + delta + len(s.matches)) % len(s.matches)
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	}
+	if s.cursor >= s.offset+maxVisible {
+		s.offset = s.cursor - maxVisible + 1
+	}
+}
+
+func (s *state) toggleAll() {
+	all := true
+	for _, item := range s.matches {
+		all = all && s.selected[item]
+	}
+	for _, item := range s.matches {
+		s.selected[item] = !all
+	}
+}
+
+// result returns the chosen items, in their original order
+func (s *state) result() []string {
+	if s.multi {
+		var chosen []string
+		for _, item := range s.items {
+			if s.selected[item] {
+				chosen = append(chosen, item)
+			}
+		}
+		if len(chosen) > 0 {
+			return chosen
+		}
+	}
+	if len(s.matches) == 0 {
+		return nil
+	}
+	return []string{s.matches[s.cursor]}
+}
+
+// draw renders the picker, replacing the previous rendering. The terminal is
+// in raw mode, so lines end in \r\n. Lines are cut to the terminal's width,
+// as wrapped lines would break clearing the previous rendering.
+func (s *state) draw(w io.Writer) {
+	s.clear(w)
+
+	width := 80
+	if cols, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && cols > 0 {
+		width = cols
+	}
+	line := func(text string) string {
+		return truncate(text, width-1)
+	}
+
+	var b strings.Builder
+	help := "↑/↓ move, Enter select, Esc cancel"
+	if s.multi {
+		help = "↑/↓ move, Tab toggle, Ctrl-A toggle all, Enter confirm, Esc cancel"
+	}
+	fmt.Fprintf(&b, "%s\r\n", line(s.title))
+	fmt.Fprintf(&b, "\x1b[2m%s\x1b[0m\r\n", line(help))
+	fmt.Fprintf(&b, "%s\r\n", line("> "+s.query))
+	lines := 3
+
+	end := min(s.offset+maxVisible, len(s.matches))
+	for i := s.offset; i < end; i++ {
+		item := s.matches[i]
+		pointer := "  "
+		if i == s.cursor {
+			pointer = "❯ "
+		}
+		check := ""
+		if s.multi {
+			check = "[ ] "
+			if s.selected[item] {
+				check = "[x] "
+			}
+		}
+		if i == s.cursor {
+			fmt.Fprintf(&b, "\x1b[1m%s\x1b[0m\r\n", line(pointer+check+item))
+		} else {
+			fmt.Fprintf(&b, "%s\r\n", line(pointer+check+item))
+		}
+		lines++
+	}
+
+	status := fmt.Sprintf("  %d/%d", len(s.matches), len(s.items))
+	if s.multi {
+		status += fmt.Sprintf(", %d selected", s.countSelected())
+	}
+	b.WriteString(line(status))
+
+	s.lines = lines
+	io.WriteString(w, b.String())
+}
+
+// truncate cuts text to at most width characters, ending in … if cut
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	if width < 1 {
+		return ""
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func (s *state) countSelected() int {
+	n := 0
+	for _, selected := range s.selected {
+		if selected {
+			n++
+		}
+	}
+	return n
+}
+
+// clear erases the previous rendering and leaves the cursor where it started
+func (s *state) clear(w io.Writer) {
+	if s.lines == 0 {
+		return
+	}
+	// The cursor is at the end of the status line, below the drawn lines
+	fmt.Fprintf(w, "\r\x1b[%dA\x1b[J", s.lines)
+	s.lines = 0
+}
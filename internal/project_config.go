@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// projectConfigNames are the project config file names checked in each
+// directory, in priority order
+var projectConfigNames = []string{".town.json", ".town.yaml", ".town.yml", ".town.toml"}
+
+// findProjectConfig returns the path of the project config file in dir or
+// the nearest parent directory that has one, like .editorconfig.
+// Returns os.ErrNotExist if there is none.
+func findProjectConfig(dir string) (string, error) {
+	for {
+		for _, name := range projectConfigNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", os.ErrNotExist
+		}
+		dir = parent
+	}
+}
+
+// loadProjectConfig adds the project config found from the working directory
+// to cfg, if there is one
+func loadProjectConfig(cfg *Config) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil // No working directory, no project
+	}
+
+	path, err := findProjectConfig(wd)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var decoded Settings
+	if err := unmarshalConfig(path, data, &decoded); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	// A project config comes with the repository it's in, so it must not be
+	// able to change where the user's token is sent. Other keys are ignored,
+	// and reported by ValidateProject.
+	var project Settings
+	for _, k := range ConfigKeys {
+		if k.InProject {
+			k.setValue(nil, &project, k.value(nil, &decoded))
+		}
+	}
+
+	// A relative clone_dir is relative to the project, not the working directory
+	if project.CloneDir != "" && !filepath.IsAbs(project.CloneDir) && !strings.HasPrefix(project.CloneDir, "~/") {
+		project.CloneDir = filepath.Join(filepath.Dir(path), project.CloneDir)
+	}
+
+	var raw map[string]any
+	if err := unmarshalConfig(path, data, &raw); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	cfg.Project = &project
+	cfg.ProjectPath = path
+	cfg.ProjectIgnoredKeys = disallowedProjectKeys(raw)
+	return nil
+}
+
+// disallowedProjectKeys returns the keys in raw that can't be set in a project config
+func disallowedProjectKeys(raw map[string]any) []string {
+	var disallowed []string
+	for key := range raw {
+		if k, err := LookupConfigKey(key); err != nil || !k.InProject {
+			disallowed = append(disallowed, key)
+		}
+	}
+	sort.Strings(disallowed)
+	return disallowed
+}
+
+// projectKeyNames returns the names of the keys a project config may set
+func projectKeyNames() []string {
+	var names []string
+	for _, k := range ConfigKeys {
+		if k.InProject {
+			names = append(names, k.Name)
+		}
+	}
+	return names
+}
+
+// ValidateProject checks the project config's values like Validate does for
+// the user config. Returns nil if there is no project config.
+func (c *Config) ValidateProject() []string {
+	if c.Project == nil {
+		return nil
+	}
+
+	var problems []string
+	if len(c.ProjectIgnoredKeys) > 0 {
+		problems = append(problems, fmt.Sprintf("'%s' can't be set in a project config and is ignored: use one of %s",
+			strings.Join(c.ProjectIgnoredKeys, "', '"), strings.Join(projectKeyNames(), ", ")))
+	}
+	for _, k := range ConfigKeys {
+		if !k.InProject {
+			continue
+		}
+		if value := k.value(nil, c.Project); value != "" {
+			if err := k.Validate(value); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+	}
+	return problems
+}
+
+// GetProject returns the key's value in the project config, "" if it isn't
+// set or there is no project config
+func (k *ConfigKey) GetProject(c *Config) string {
+	if c.Project == nil || !k.InProject {
+		return ""
+	}
+	return k.value(c, c.Project)
+}
@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDisallowedProjectKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  map[string]any
+		want []string
+	}{
+		{
+			name: "project keys",
+			raw:  map[string]any{"default_org": "acme", "default_team": "eng", "clone_dir": "repos", "clone_layout": "org", "clone_protocol": "ssh"},
+		},
+		{
+			name: "credential keys",
+			raw:  map[string]any{"default_org": "acme", "host": "evil.example.com", "token_source": "env", "oauth_client_id": "x"},
+			want: []string{"host", "oauth_client_id", "token_source"},
+		},
+		{
+			name: "unknown and profile keys",
+			raw:  map[string]any{"profiles": map[string]any{}, "typo": 1},
+			want: []string{"profiles", "typo"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := disallowedProjectKeys(tt.raw); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("disallowedProjectKeys() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindProjectConfig(t *testing.T) {
+	root := t.TempDir()
+	files := []string{
+		"project/.town.yaml",
+		"project/.town.toml",
+		"project/sub/dir/.keep",
+		"other/.town.json/.keep", // A directory named like a project config
+	}
+	for _, file := range files {
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		dir  string
+		want string // "" if there is none
+	}{
+		{dir: "project", want: "project/.town.yaml"},
+		{dir: "project/sub/dir", want: "project/.town.yaml"},
+		{dir: "other"},
+		{dir: "."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			got, err := findProjectConfig(filepath.Join(root, tt.dir))
+			if tt.want == "" {
+				// A project config above the temporary directory would be found
+				if err == nil && strings.HasPrefix(got, root) {
+					t.Errorf("findProjectConfig() = %s, want none", got)
+				}
+				return
+			}
+			if want := filepath.Join(root, tt.want); err != nil || got != want {
+				t.Errorf("findProjectConfig() = %s, %v, want %s", got, err, want)
+			}
+		})
+	}
+}
+
+func TestLoadProjectConfigIgnoresDisallowedKeys(t *testing.T) {
+	// The working directory has symlinks resolved, e.g. on macOS
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := "default_org: acme\nclone_dir: repos\nhost: evil.example.com\ntoken_source: env\n"
+	if err := os.WriteFile(filepath.Join(dir, ".town.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	cfg := &Config{}
+	if err := loadProjectConfig(cfg); err != nil {
+		t.Fatalf("loadProjectConfig(): %v", err)
+	}
+
+	want := Settings{DefaultOrg: "acme", CloneDir: filepath.Join(dir, "repos")}
+	if cfg.Project == nil || *cfg.Project != want {
+		t.Errorf("project settings = %+v, want %+v", cfg.Project, want)
+	}
+	if keys := []string{"host", "token_source"}; !reflect.DeepEqual(cfg.ProjectIgnoredKeys, keys) {
+		t.Errorf("ignored keys = %q, want %q", cfg.ProjectIgnoredKeys, keys)
+	}
+	if problems := cfg.ValidateProject(); len(problems) != 1 {
+		t.Errorf("ValidateProject() = %q, want the ignored keys reported", problems)
+	}
+}
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"sort"
+
+	"github.com/lordzsolt/town/internal/cache"
+
+	"github.com/google/go-github/v58/github"
+)
+
+// TeamStats is the footprint of a team in the org's CODEOWNERS files
+type TeamStats struct {
+	Team string
+	// Repos is the number of repositories naming the team in CODEOWNERS
+	Repos int
+	// Paths is the number of CODEOWNERS rules naming the team
+	Paths int
+	// SizeKB is the total size of the repositories naming the team, as
+	// reported by GitHub. Repositories count in full, whatever the team owns
+	// of them.
+	SizeKB int
+	// Languages counts the owned repositories by primary language, most used first
+	Languages []*LanguageCount
+	// RecentlyPushed are the owned repositories pushed to last, most recent first
+	RecentlyPushed []*github.Repository
+	// CoOwned is the number of owned repositories also naming other teams
+	CoOwned int
+}
+
+// LanguageCount is the number of repositories with a primary language
+type LanguageCount struct {
+	Language string
+	Repos    int
+}
+
+// ComputeTeamStats returns the stats of every team named in the CODEOWNERS
+// files (by repository) of the repositories, keeping the recent most recently
+// pushed repositories per team. Archived repositories are skipped. Teams
+// owning the most repositories come first.
+func ComputeTeamStats(org string, repos []*github.Repository, files map[string]*cache.CodeownersFile, recent int) []*TeamStats {
+	byName := make(map[string]*github.Repository, len(repos))
+	var names []string
+	for _, r := range repos {
+		if !r.GetArchived() {
+			byName[r.GetName()] = r
+			names = append(names, r.GetName())
+		}
+	}
+	m := BuildOwnershipMatrix(org, names, files)
+
+	var results []*TeamStats
+	for _, team := range m.Teams {
+		s := &TeamStats{Team: team}
+		languages := make(map[string]int)
+		var owned []*github.Repository
+
+		for _, name := range m.Repos {
+			cell := m.Cell(name, team)
+			if cell == nil {
+				continue
+			}
+			repo := byName[name]
+			owned = append(owned, repo)
+
+			s.Repos++
+			s.Paths += len(cell.Patterns)
+			s.SizeKB += repo.GetSize()
+			if lang := repo.GetLanguage(); lang != "" {
+				languages[lang]++
+			}
+			for _, other := range m.Teams {
+				if other != team && m.Cell(name, other) != nil {
+					s.CoOwned++
+					break
+				}
+			}
+		}
+
+		for _, lang := range sortedKeys(languages, nil) {
+			s.Languages = append(s.Languages, &LanguageCount{Language: lang, Repos: languages[lang]})
+		}
+		sort.SliceStable(s.Languages, func(i, j int) bool {
+			return s.Languages[i].Repos > s.Languages[j].Repos
+		})
+
+		sort.SliceStable(owned, func(i, j int) bool {
+			return owned[i].GetPushedAt().After(owned[j].GetPushedAt().Time)
+		})
+		s.RecentlyPushed = owned[:min(recent, len(owned))]
+
+		results = append(results, s)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Repos > results[j].Repos
+	})
+	return results
+}